@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/refactor"
+)
+
+var backlinksDryRun bool
+
+var backlinksCmd = &cobra.Command{
+	Use:   "backlinks [dir]",
+	Short: "Write a backlinks section to each document",
+	Long: `Backlinks finds the documents under dir, the current directory by default,
+that link to each markdown file and writes them as a "## Backlinks" list of
+titles, taken from the front matter or first heading, with relative links.
+The list is kept between ` + refactor.BacklinksStart + ` and
+` + refactor.BacklinksEnd + ` comments at the end of the file and
+nothing outside them changes. Documents nothing links to get no section, and
+running it again on unchanged documents writes nothing. With --dry-run the
+changes are printed as a unified diff and nothing is written.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+		plan, err := refactor.Backlinks(root)
+		if err != nil {
+			return err
+		}
+		if err := planLineEndings(plan); err != nil {
+			return err
+		}
+		if backlinksDryRun {
+			return plan.Diff(cmd.OutOrStdout())
+		}
+		if err := plan.Apply(); err != nil {
+			return err
+		}
+		LoggerFrom(cmd.Context()).Info("Updated backlinks", "files", len(plan.Changes))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backlinksCmd)
+
+	backlinksCmd.Flags().BoolVarP(&backlinksDryRun, "dry-run", "n", false, "print a diff of the changes instead of applying them")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/include"
+	"github.com/gkwa/bravewaldo/internal/vars"
+)
+
+var (
+	buildOutput string
+	buildHTML   bool
+	buildVars   bool
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build <file>",
+	Short: "Resolve include directives into a flattened document",
+	Long: `Build replaces the include directives of a markdown file, such as
+<!-- include: shared/setup.md#install --> or {{< include "main.go" lines="3-9" >}},
+with the content they name and prints the flattened document. Included
+markdown has its own includes resolved and its relative links rebased; a
+#fragment picks the section under that heading. Other files are included as
+fenced code blocks. Include cycles are reported as errors. --vars then
+replaces {{ .name }} placeholders in the flattened document, included files
+and all, as subst does, and --html renders the result to HTML instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, err := include.ResolveSource(path, source)
+		if err != nil {
+			return err
+		}
+		// Included files are written with the line endings of the
+		// document.
+		out = eol.Match(source, out, "")
+		if buildVars {
+			out = vars.Substitute(out, varsOptions(false)).Output
+		}
+
+		if buildHTML {
+			var buf bytes.Buffer
+			if err := core.NewGoldmark().Convert(out, &buf); err != nil {
+				return err
+			}
+			out = buf.Bytes()
+		}
+		return writeOutput(cmd, buildOutput, func(w io.Writer) error {
+			_, err := w.Write(out)
+			return err
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+
+	buildCmd.Flags().StringVarP(&buildOutput, "output", "o", "-", "file to write the document to, - for stdout")
+	buildCmd.Flags().BoolVar(&buildHTML, "html", false, "render the flattened document to HTML")
+	buildCmd.Flags().BoolVar(&buildVars, "vars", false, "replace {{ .name }} placeholders in the flattened document")
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gkwa/bravewaldo/internal/testutil"
+)
+
+func TestBuildVarsInIncludes(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"guide.md":        "---\nversion: v2\n---\n# Guide\n\n<!-- include: shared/links.md -->\n",
+		"shared/links.md": "See https://docs.example.com/{{ .version }}/.\n",
+	})
+	defer func() {
+		buildVars = false
+	}()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"build", "--vars", filepath.Join(dir, "guide.md")})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := "---\nversion: v2\n---\n# Guide\n\nSee https://docs.example.com/v2/.\n"
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("build --vars mismatch (-want +got):\n%s", diff)
+	}
+}
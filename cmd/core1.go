@@ -9,12 +9,16 @@ var core1Cmd = &cobra.Command{
 	Use:   "core1",
 	Short: "A brief description of your command",
 	Long:  `A longer description that spans multiple lines and likely contains examples and usage of using your command.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		logger := LoggerFrom(cmd.Context())
-		core.Example(logger)
+		return runInputs(cmd, []string{defaultInput}, func(string) error {
+			core.Example(logger)
+			return nil
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(core1Cmd)
+	addDefaultInputWatchFlag(core1Cmd)
 }
@@ -10,7 +10,7 @@ import (
 
 // core10Cmd represents the core10 command
 var core10Cmd = &cobra.Command{
-	Use:   "core10",
+	Use:   "core10 [file|dir...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -18,12 +18,36 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if reverse {
+			return runUnrewrite(cmd, args)
+		}
 		logger := LoggerFrom(cmd.Context())
-		core10.Main(logger)
+		if len(args) == 0 && !gitSelection(cmd) {
+			return runInputs(cmd, []string{defaultInput}, func(string) error {
+				urlMap, err := loadURLMap()
+				if err != nil {
+					return err
+				}
+				core10.Main(logger, urlMap)
+				return convertLineEndings(defaultOutput)
+			})
+		}
+		return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+			urlMap, err := loadURLMap()
+			if err != nil {
+				return err
+			}
+			if err := core10.RewriteFile(logger, path, path, urlMap); err != nil {
+				return err
+			}
+			return convertLineEndings(path)
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(core10Cmd)
+	addWatchFlag(core10Cmd)
+	addReverseFlags(core10Cmd)
 }
@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/gkwa/bravewaldo/core11"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // core11Cmd represents the core11 command
 var core11Cmd = &cobra.Command{
-	Use:   "core11",
+	Use:   "core11 [file|dir...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -15,16 +19,53 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		err := core11.Main()
-		if err != nil {
-			panic(err)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if reverse {
+			return runUnrewrite(cmd, args)
+		}
+		if len(args) == 0 && !gitSelection(cmd) {
+			return runInputs(cmd, []string{defaultInput}, func(string) error {
+				urlMap, options, err := core11Options()
+				if err != nil {
+					return err
+				}
+				return core11.Main(urlMap, options)
+			})
 		}
+		return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+			urlMap, options, err := core11Options()
+			if err != nil {
+				return err
+			}
+			return core11.ProcessFile(path, path, urlMap, options)
+		})
 	},
 }
 
+// core11Options returns the URL map and the processing options set by the
+// flags and config file, reloaded for every file so --watch sees changes.
+func core11Options() (map[string]string, core11.ProcessOptions, error) {
+	urlMap, err := loadURLMap()
+	if err != nil {
+		return nil, core11.ProcessOptions{}, err
+	}
+	ending, err := lineEnding()
+	if err != nil {
+		return nil, core11.ProcessOptions{}, err
+	}
+	return urlMap, core11.ProcessOptions{LineEnding: ending, MaxSize: viper.GetInt64("core11.max-size")}, nil
+}
+
 func init() {
 	rootCmd.AddCommand(core11Cmd)
+	addWatchFlag(core11Cmd)
+	addReverseFlags(core11Cmd)
+	core11Cmd.Flags().Int64("max-size", 0, "reject files larger than this many bytes, 0 for no limit")
+
+	if err := viper.BindPFlag("core11.max-size", core11Cmd.Flags().Lookup("max-size")); err != nil {
+		fmt.Printf("Error binding max-size flag: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Here you will define your flags and configuration settings.
 
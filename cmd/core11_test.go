@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gkwa/bravewaldo/core11"
+)
+
+func TestCore11MaxSizeWithoutArgs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "testdata"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	input := "See https://example.com/" + strings.Repeat("x", 200) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, defaultInput), []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	defer func() {
+		if err := core11Cmd.Flags().Set("max-size", "0"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	rootCmd.SetArgs([]string{"core11", "--max-size", "100"})
+	if err := rootCmd.Execute(); !errors.Is(err, core11.ErrTooLarge) {
+		t.Fatalf("got error %v, want %v", err, core11.ErrTooLarge)
+	}
+	if _, err := os.Stat(filepath.Join(dir, defaultOutput)); !os.IsNotExist(err) {
+		t.Errorf("%s was written over the limit", defaultOutput)
+	}
+
+	rootCmd.SetArgs([]string{"core11", "--max-size", "1000"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, defaultOutput)); err != nil {
+		t.Errorf("%s was not written under the limit: %v", defaultOutput, err)
+	}
+}
@@ -1,13 +1,15 @@
 package cmd
 
 import (
+	"io"
+
 	"github.com/gkwa/bravewaldo/core3"
 	"github.com/spf13/cobra"
 )
 
 // core3Cmd represents the core3 command
 var core3Cmd = &cobra.Command{
-	Use:   "core3",
+	Use:   "core3 [file|dir...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -15,13 +17,18 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		core3.Main()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+			return withLineEndings(cmd.OutOrStdout(), func(w io.Writer) error {
+				return core3.FormatFile(w, path)
+			})
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(core3Cmd)
+	addWatchFlag(core3Cmd)
 
 	// Here you will define your flags and configuration settings.
 
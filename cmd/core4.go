@@ -14,12 +14,16 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		logger := LoggerFrom(cmd.Context())
-		core4.Main(logger)
+		return runInputs(cmd, []string{defaultInput}, func(string) error {
+			core4.Main(logger)
+			return nil
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(core4Cmd)
+	addDefaultInputWatchFlag(core4Cmd)
 }
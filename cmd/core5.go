@@ -5,9 +5,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var core5Relaxed bool
+
 // core5Cmd represents the core5 command
 var core5Cmd = &cobra.Command{
-	Use:   "core5",
+	Use:   "core5 [file|dir...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -15,13 +17,38 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		core5.Main()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options := func() (core5.Options, error) {
+			urlMap, err := loadURLMap()
+			return core5.Options{URLMap: urlMap, Relaxed: core5Relaxed}, err
+		}
+		if len(args) == 0 && !gitSelection(cmd) {
+			return runInputs(cmd, []string{defaultInput}, func(string) error {
+				opts, err := options()
+				if err != nil {
+					return err
+				}
+				core5.Main(opts)
+				return convertLineEndings(defaultOutput)
+			})
+		}
+		return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+			opts, err := options()
+			if err != nil {
+				return err
+			}
+			if err := core5.ProcessFile(path, path, opts); err != nil {
+				return err
+			}
+			return convertLineEndings(path)
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(core5Cmd)
+	addWatchFlag(core5Cmd)
+	core5Cmd.Flags().BoolVar(&core5Relaxed, "relaxed", false, "also link scheme-less www. hosts")
 
 	// Here you will define your flags and configuration settings.
 
@@ -1,13 +1,15 @@
 package cmd
 
 import (
+	"io"
+
 	"github.com/gkwa/bravewaldo/core8"
 	"github.com/spf13/cobra"
 )
 
 // core8Cmd represents the core8 command
 var core8Cmd = &cobra.Command{
-	Use:   "core8",
+	Use:   "core8 [file|dir...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -15,11 +17,16 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		core8.Main()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+			return withLineEndings(cmd.OutOrStdout(), func(w io.Writer) error {
+				return core8.FormatFile(w, path)
+			})
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(core8Cmd)
+	addWatchFlag(core8Cmd)
 }
@@ -4,13 +4,15 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"io"
+
 	"github.com/gkwa/bravewaldo/core9"
 	"github.com/spf13/cobra"
 )
 
 // core9Cmd represents the core9 command
 var core9Cmd = &cobra.Command{
-	Use:   "core9",
+	Use:   "core9 [file|dir...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -18,13 +20,18 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		core9.Main()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+			return withLineEndings(cmd.OutOrStdout(), func(w io.Writer) error {
+				return core9.FormatFile(w, path)
+			})
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(core9Cmd)
+	addWatchFlag(core9Cmd)
 
 	// Here you will define your flags and configuration settings.
 
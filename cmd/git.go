@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/gitfiles"
+)
+
+func addGitFlags(cmd *cobra.Command) {
+	cmd.Flags().String("changed-since", "", "only process markdown files changed since this git revision, including uncommitted changes")
+	cmd.Flags().Bool("staged", false, "only process markdown files staged in git")
+}
+
+// gitSelection reports whether --changed-since or --staged picks the files.
+func gitSelection(cmd *cobra.Command) bool {
+	rev, _ := cmd.Flags().GetString("changed-since")
+	staged, _ := cmd.Flags().GetBool("staged")
+	return rev != "" || staged
+}
+
+// defaultArgs returns args, or the paths to process when none are given:
+// the whole working directory when git picks the files, otherwise the
+// default input.
+func defaultArgs(cmd *cobra.Command, args []string) []string {
+	switch {
+	case len(args) > 0:
+		return args
+	case gitSelection(cmd):
+		return []string{"."}
+	}
+	return []string{defaultInput}
+}
+
+// gitFilter keeps the paths that --changed-since or --staged select, or
+// returns paths unchanged when neither is set.
+func gitFilter(cmd *cobra.Command, paths []string) ([]string, error) {
+	if !gitSelection(cmd) {
+		return paths, nil
+	}
+
+	var selected map[string]bool
+	var err error
+	if rev, _ := cmd.Flags().GetString("changed-since"); rev != "" {
+		selected, err = gitfiles.Changed(".", rev)
+	} else {
+		selected, err = gitfiles.Staged(".")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if selected[abs] {
+			result = append(result, path)
+		}
+	}
+	return result, nil
+}
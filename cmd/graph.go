@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/graph"
+)
+
+var (
+	graphFormat string
+	graphOutput string
+	graphOpts   graph.Options
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [dir]",
+	Short: "Render the link graph of a documentation tree",
+	Long: `Graph walks the markdown files under dir, the current directory by
+default, and renders the directed graph their relative links and [[wikilinks]]
+form as Graphviz DOT, a Mermaid flowchart or GraphML. --external adds linked
+domains as a cluster of nodes and --broken adds highlighted edges to link
+targets that do not exist. --start limits the graph to the documents reachable
+from one document, within --depth links when given.`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(graph.Formats, graphFormat) {
+			return fmt.Errorf("unknown format %q, expected one of %s", graphFormat, strings.Join(graph.Formats, ", "))
+		}
+		if graphOpts.Depth < 0 {
+			return fmt.Errorf("--depth must not be negative")
+		}
+		if graphOpts.Depth > 0 && graphOpts.Start == "" {
+			return fmt.Errorf("--depth requires --start")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+		g, err := graph.Build(root, graphOpts)
+		if err != nil {
+			return err
+		}
+		return writeOutput(cmd, graphOutput, func(w io.Writer) error {
+			return g.Write(w, graphFormat)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().StringVar(&graphFormat, "format", graph.FormatDOT, "output format: "+strings.Join(graph.Formats, ", "))
+	graphCmd.Flags().StringVarP(&graphOutput, "output", "o", "-", "file to write the graph to, - for stdout")
+	graphCmd.Flags().BoolVar(&graphOpts.External, "external", false, "add linked external domains as clustered nodes")
+	graphCmd.Flags().BoolVar(&graphOpts.Broken, "broken", false, "add highlighted edges to missing link targets")
+	graphCmd.Flags().StringVar(&graphOpts.Start, "start", "", "only include documents reachable from this document, relative to dir")
+	graphCmd.Flags().IntVar(&graphOpts.Depth, "depth", 0, "follow at most this many links from --start, 0 for no limit")
+	graphCmd.Flags().BoolVar(&graphOpts.ExcludeOrphans, "exclude-orphans", false, "leave out nodes without edges")
+}
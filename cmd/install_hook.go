@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/gitfiles"
+)
+
+// hookMarker identifies pre-commit hooks written by install-hook, which may
+// be overwritten without --force.
+const hookMarker = "# Installed by bravewaldo install-hook."
+
+var (
+	installHookCommand string
+	installHookForce   bool
+)
+
+var installHookCmd = &cobra.Command{
+	Use:   "install-hook",
+	Short: "Install a git pre-commit hook that lints staged markdown",
+	Long: `Install-hook writes a pre-commit hook into the current git repository that
+runs "bravewaldo lint --staged", so each commit only checks the markdown files
+it changes. An existing hook that was not written by install-hook is only
+replaced with --force.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := gitfiles.HooksDir(".")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, "pre-commit")
+
+		if existing, err := os.ReadFile(path); err == nil && !installHookForce && !strings.Contains(string(existing), hookMarker) {
+			return fmt.Errorf("%s already exists; use --force to replace it", path)
+		}
+
+		hook := fmt.Sprintf("#!/bin/sh\n%s\nexec %s\n", hookMarker, installHookCommand)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+		if err := os.WriteFile(path, []byte(hook), 0o755); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		LoggerFrom(cmd.Context()).Info("Installed pre-commit hook", "path", path)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(installHookCmd)
+
+	installHookCmd.Flags().StringVar(&installHookCommand, "command", "bravewaldo lint --staged", "command the hook runs")
+	installHookCmd.Flags().BoolVar(&installHookForce, "force", false, "replace an existing pre-commit hook")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/inventory"
+)
+
+var (
+	inventoryJSON   string
+	inventoryReport string
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory [dir]",
+	Short: "Report the links and backlinks of a documentation tree",
+	Long: `Inventory walks the markdown files under dir, the current directory by
+default, and lists every external URL after normalization with the files and
+lines that reference it, and every document with the documents that link to
+it. Documents without backlinks are reported as orphaned. The inventory is
+written as JSON, to stdout unless --json names a file, and --report also
+writes it as a markdown page.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+		inv, err := inventory.Build(root)
+		if err != nil {
+			return err
+		}
+
+		if err := writeOutput(cmd, inventoryJSON, inv.WriteJSON); err != nil {
+			return err
+		}
+		if inventoryReport != "" {
+			return writeOutput(cmd, inventoryReport, inv.WriteMarkdown)
+		}
+		return nil
+	},
+}
+
+// writeOutput calls write with stdout when path is "-", or with the file
+// at path otherwise, converting line endings as set with --line-endings.
+func writeOutput(cmd *cobra.Command, path string, write func(w io.Writer) error) error {
+	if path == "-" {
+		return withLineEndings(cmd.OutOrStdout(), write)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := withLineEndings(f, write); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(inventoryCmd)
+
+	inventoryCmd.Flags().StringVar(&inventoryJSON, "json", "-", "file to write the JSON inventory to, - for stdout")
+	inventoryCmd.Flags().StringVar(&inventoryReport, "report", "", "file to write a markdown report page to, - for stdout")
+}
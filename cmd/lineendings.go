@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/viper"
+
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/refactor"
+)
+
+// lineEnding returns the line ending set with --line-endings, or "" to
+// keep each document's own.
+func lineEnding() (string, error) {
+	return eol.ParseLineEnding(viper.GetString("line-endings"))
+}
+
+// withLineEndings calls write with w, converting what it writes to the
+// line ending set with --line-endings.
+func withLineEndings(w io.Writer, write func(w io.Writer) error) error {
+	ending, err := lineEnding()
+	if err != nil {
+		return err
+	}
+	ew := eol.NewWriter(w, ending)
+	if err := write(ew); err != nil {
+		return err
+	}
+	return ew.Close()
+}
+
+// planLineEndings makes plan write the line ending set with
+// --line-endings, if one is.
+func planLineEndings(plan *refactor.Plan) error {
+	ending, err := lineEnding()
+	if err != nil {
+		return err
+	}
+	plan.SetLineEnding(ending)
+	return nil
+}
+
+// processLineEndings runs fn over source with LF line endings and no byte
+// order mark, and returns its result written like source, or with the
+// line ending set with --line-endings.
+func processLineEndings(source []byte, fn func([]byte) []byte) ([]byte, error) {
+	ending, err := lineEnding()
+	if err != nil {
+		return nil, err
+	}
+	return eol.Process(source, ending, func(normalized []byte) ([]byte, error) {
+		return fn(normalized), nil
+	})
+}
+
+// convertLineEndings rewrites the file at path with the line ending set
+// with --line-endings, if one is.
+func convertLineEndings(path string) error {
+	ending, err := lineEnding()
+	if err != nil || ending == "" {
+		return err
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	converted := eol.Convert(source, ending)
+	if string(converted) == string(source) {
+		return nil
+	}
+	if err := os.WriteFile(path, converted, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBacklinksLineEndings(t *testing.T) {
+	defer func() {
+		if err := rootCmd.PersistentFlags().Set("line-endings", "auto"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	for _, tt := range []struct {
+		lineEndings string
+		want        string
+	}{
+		{"auto", "\ufeff# Note\r\n\r\nBody.\r\n\r\n<!-- backlinks:start -->\r\n## Backlinks\r\n\r\n- [Index](index.md)\r\n<!-- backlinks:end -->\r\n"},
+		{"lf", "\ufeff# Note\n\nBody.\n\n<!-- backlinks:start -->\n## Backlinks\n\n- [Index](index.md)\n<!-- backlinks:end -->\n"},
+	} {
+		dir := t.TempDir()
+		for name, content := range map[string]string{
+			"index.md": "\ufeff# Index\r\n\r\nSee [the note](note.md).\r\n",
+			"note.md":  "\ufeff# Note\r\n\r\nBody.\r\n",
+		} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rootCmd.SetArgs([]string{"backlinks", "--line-endings", tt.lineEndings, dir})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "note.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+			t.Errorf("--line-endings %s: note.md mismatch (-want +got):\n%s", tt.lineEndings, diff)
+		}
+	}
+}
+
+func TestFormatLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.md")
+	if err := os.WriteFile(input, []byte("# T\r\n\r\ntext\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, command := range []string{"core3", "core9"} {
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{command, input})
+		err := rootCmd.Execute()
+		rootCmd.SetOut(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff("# T\r\n\r\ntext\r\n", out.String()); diff != "" {
+			t.Errorf("%s output mismatch (-want +got):\n%s", command, diff)
+		}
+	}
+}
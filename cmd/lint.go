@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/gitfiles"
+	"github.com/gkwa/bravewaldo/internal/report"
+	"github.com/gkwa/bravewaldo/lint"
+)
+
+var (
+	lintFix       bool
+	lintListRules bool
+	lintRules     []string
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [file|dir...]",
+	Short: "Check markdown files against lint rules",
+	Long: `Lint runs rules over the goldmark AST of each markdown file and prints one
+line per finding. Rules are configured under lint.rules in .bravewaldo.yaml,
+where each rule accepts enabled, severity and rule specific options. With
+--fix, findings that have a fix are corrected in place. --rule runs only
+the given rules, so --rule broken-link checks links and --rule bare-url
+--rule inconsistent-label checks consistency with the URL map. --format
+selects SARIF, JUnit, GitHub Actions annotations or checkstyle output for
+CI.
+With --staged the content staged in the git index is linted, not the
+working tree copy, so the pre-commit hook checks what is committed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if lintListRules {
+			for _, rule := range lint.Rules() {
+				fixable := ""
+				if rule.Fixable {
+					fixable = " (fixable)"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%-20s %-8s %s%s\n", rule.ID, rule.Severity, rule.Description, fixable)
+			}
+			return nil
+		}
+
+		staged, _ := cmd.Flags().GetBool("staged")
+		if staged && lintFix {
+			return fmt.Errorf("--fix cannot be combined with --staged, which lints the index rather than the files")
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		if err := report.ValidateFormat(format); err != nil {
+			return err
+		}
+
+		config, err := lint.ParseConfig(viper.GetStringMap("lint.rules"))
+		if err != nil {
+			return err
+		}
+		if len(lintRules) > 0 {
+			if config, err = config.Only(lintRules); err != nil {
+				return err
+			}
+		}
+		urlMap, err := loadURLMap()
+		if err != nil {
+			return err
+		}
+
+		paths, err := files.Markdown(defaultArgs(cmd, args))
+		if err != nil {
+			return err
+		}
+		paths, err = gitFilter(cmd, paths)
+		if err != nil {
+			return err
+		}
+
+		r := report.Report{Tool: "lint", Paths: paths}
+		for _, rule := range lint.Rules() {
+			if rc := config[rule.ID]; rc.Enabled != nil && !*rc.Enabled {
+				continue
+			}
+			r.Rules = append(r.Rules, report.Rule{ID: rule.ID, Description: rule.Description})
+		}
+		for _, path := range paths {
+			var findings []lint.Finding
+			if staged {
+				source, err := gitfiles.ReadStaged(path)
+				if err != nil {
+					return err
+				}
+				findings = lint.Lint(lint.Parse(path, source, urlMap), config)
+			} else if findings, err = lint.File(path, config, urlMap, lintFix); err != nil {
+				return err
+			}
+			for _, f := range findings {
+				r.Findings = append(r.Findings, report.Finding{
+					Rule:      f.Rule,
+					Severity:  report.Severity(f.Severity),
+					Path:      f.Path,
+					Line:      f.Position.Line,
+					Column:    f.Position.Column,
+					EndLine:   f.End.Line,
+					EndColumn: f.End.Column,
+					Message:   f.Message,
+				})
+			}
+		}
+		return writeReport(cmd, r)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "fix findings in place where the rule supports it")
+	addFormatFlag(lintCmd)
+	addGitFlags(lintCmd)
+	lintCmd.Flags().StringSliceVar(&lintRules, "rule", nil, "run only this rule; may be repeated")
+	lintCmd.Flags().BoolVar(&lintListRules, "list-rules", false, "list the available rules and exit")
+}
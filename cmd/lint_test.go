@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestLintStaged(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(path, []byte("# Doc\n\nSee [gone](gone.md).\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("doc.md"); err != nil {
+		t.Fatal(err)
+	}
+	// The working tree fixes the link, but the commit would not.
+	if err := os.WriteFile(path, []byte("# Doc\n\nSee [doc](doc.md).\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	defer func() {
+		for _, flag := range []string{"staged", "fix"} {
+			if err := lintCmd.Flags().Set(flag, "false"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"lint", "--staged"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("lint --staged passed, want the broken link in the index reported")
+	}
+	if !strings.Contains(out.String(), "gone.md does not exist") {
+		t.Errorf("output does not report the staged link:\n%s", out.String())
+	}
+
+	rootCmd.SetArgs([]string{"lint", "--staged", "--fix"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--fix") {
+		t.Errorf("lint --staged --fix error = %v, want it rejected", err)
+	}
+}
+
+func TestLintRuleFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(path, []byte("# Doc\n\nSee [gone](nowhere-at-all.md). \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		lintRules = nil
+		if err := lintCmd.Flags().Set("format", "text"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"lint", "--rule", "broken-link", "--format", "github", path})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("lint --rule broken-link passed with a broken link")
+	}
+	// The trailing whitespace is not reported.
+	want := "::error file=" + path + ",line=3,col=5,endLine=3,endColumn=30,title=broken-link::link target nowhere-at-all.md does not exist\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	rootCmd.SetArgs([]string{"lint", "--rule", "no-such-rule", path})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "no-such-rule") {
+		t.Errorf("lint --rule no-such-rule error = %v, want it rejected", err)
+	}
+}
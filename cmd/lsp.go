@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/lsp"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a Language Server Protocol server over stdio",
+	Long: `Lsp speaks JSON-RPC over stdin and stdout. It publishes diagnostics for
+bare URLs that have a URL map entry, broken relative links and inconsistent
+link labels, offers the core11 rewrite as a code action, shows URL map names
+on hover, completes URL map keys inside "](" and formats documents with the
+goldmark-markdown renderer.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := LoggerFrom(cmd.Context())
+		server := lsp.New(logger, lsp.Options{URLMap: loadURLMap})
+		return server.Serve(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+}
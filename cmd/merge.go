@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/refactor"
+)
+
+var (
+	mergeOrder  string
+	mergeIndex  string
+	mergeOutput string
+	mergeShift  int
+	mergeDryRun bool
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [file...]",
+	Short: "Merge documents into one",
+	Long: `Merge concatenates markdown files into one document, in the order they are
+given or listed one per line in the --order file. With --index the files are
+the ones linked from the lists of an index page, such as split writes, and
+the rest of the index is kept around them. Headings of the merged files are
+shifted down by --shift levels, links between them become #anchor links
+and other relative links are rebased onto --output, which is stdout by
+default. With --dry-run the change is printed as a unified diff.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if mergeIndex != "" && (mergeOrder != "" || len(args) > 0) {
+			return fmt.Errorf("--index cannot be combined with --order or file arguments")
+		}
+		paths := args
+		if mergeOrder != "" {
+			order, err := refactor.ReadOrder(mergeOrder)
+			if err != nil {
+				return err
+			}
+			paths = append(order, paths...)
+		}
+
+		var plan *refactor.Plan
+		var err error
+		if mergeIndex != "" {
+			plan, err = refactor.MergeIndex(mergeIndex, mergeOutput, mergeShift)
+		} else {
+			plan, err = refactor.Merge(paths, mergeOutput, mergeShift)
+		}
+		if err != nil {
+			return err
+		}
+		if err := planLineEndings(plan); err != nil {
+			return err
+		}
+
+		switch {
+		case mergeOutput == "-":
+			for _, c := range plan.Changes {
+				if _, err := cmd.OutOrStdout().Write(c.After); err != nil {
+					return err
+				}
+			}
+			return nil
+		case mergeDryRun:
+			return plan.Diff(cmd.OutOrStdout())
+		}
+		if err := plan.Apply(); err != nil {
+			return err
+		}
+		LoggerFrom(cmd.Context()).Info("Merged documents", "path", mergeOutput)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().StringVar(&mergeOrder, "order", "", "file listing the documents to merge, one per line")
+	mergeCmd.Flags().StringVar(&mergeIndex, "index", "", "index page whose link lists name the documents to merge")
+	mergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "-", "merged document, - for stdout")
+	mergeCmd.Flags().IntVar(&mergeShift, "shift", 0, "heading levels to shift the merged documents down by")
+	mergeCmd.Flags().BoolVarP(&mergeDryRun, "dry-run", "n", false, "print a diff of the change instead of writing it")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gkwa/bravewaldo/internal/testutil"
+)
+
+func TestMergeStdout(t *testing.T) {
+	dir := t.TempDir()
+	want := "# One\n\n# Two\n"
+	testutil.WriteFiles(t, dir, map[string]string{
+		"one.md": "# One\n",
+		"two.md": "# Two\n",
+		// An unrelated file that happens to hold the merged document.
+		"merged.md": want,
+	})
+	t.Chdir(dir)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"merge", "one.md", "two.md"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
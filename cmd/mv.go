@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/refactor"
+)
+
+var (
+	mvDryRun bool
+	mvRoot   string
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <old> <new>",
+	Short: "Move a file and update the relative links to it",
+	Long: `Mv moves a file and rewrites every relative link and image in the markdown
+files under --root that pointed at it. When the moved file is markdown, its
+own relative links are updated to keep pointing at the same targets. Only
+link destinations change. With --dry-run the changes are printed as a
+unified diff and nothing is written.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, err := refactor.Move(mvRoot, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err := planLineEndings(plan); err != nil {
+			return err
+		}
+		if mvDryRun {
+			return plan.Diff(cmd.OutOrStdout())
+		}
+		if err := plan.Apply(); err != nil {
+			return err
+		}
+		LoggerFrom(cmd.Context()).Info("Moved", "from", args[0], "to", args[1], "files", len(plan.Changes))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+
+	mvCmd.Flags().BoolVarP(&mvDryRun, "dry-run", "n", false, "print a diff of the changes instead of applying them")
+	mvCmd.Flags().StringVar(&mvRoot, "root", ".", "directory whose markdown files are searched for links")
+}
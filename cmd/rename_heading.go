@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/refactor"
+)
+
+var (
+	renameHeadingDryRun bool
+	renameHeadingRoot   string
+)
+
+var renameHeadingCmd = &cobra.Command{
+	Use:   "rename-heading <file> <heading|#id> <new text>",
+	Short: "Rename a heading and update the anchor links to it",
+	Long: `Rename-heading changes the text of a heading, chosen by its current text or
+by its ID, and updates every #anchor link whose heading ID changes as a
+result, both in the file itself and in the markdown files under --root.
+Duplicate headings are numbered the way goldmark's auto heading IDs are,
+so renaming one can renumber the others. With --dry-run the changes are
+printed as a unified diff and nothing is written.`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, err := refactor.RenameHeading(renameHeadingRoot, args[0], args[1], args[2])
+		if err != nil {
+			return err
+		}
+		if err := planLineEndings(plan); err != nil {
+			return err
+		}
+		if renameHeadingDryRun {
+			return plan.Diff(cmd.OutOrStdout())
+		}
+		if err := plan.Apply(); err != nil {
+			return err
+		}
+		LoggerFrom(cmd.Context()).Info("Renamed heading", "file", args[0], "heading", args[1], "files", len(plan.Changes))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameHeadingCmd)
+
+	renameHeadingCmd.Flags().BoolVarP(&renameHeadingDryRun, "dry-run", "n", false, "print a diff of the changes instead of applying them")
+	renameHeadingCmd.Flags().StringVar(&renameHeadingRoot, "root", ".", "directory whose markdown files are searched for links")
+}
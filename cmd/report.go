@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/report"
+)
+
+func addFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().String("format", report.FormatText, fmt.Sprintf("output format (%s)", strings.Join(report.Formats, ", ")))
+}
+
+// writeReport writes r to stdout in the format chosen with --format and
+// returns an error when any finding has error severity.
+func writeReport(cmd *cobra.Command, r report.Report) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	if err := report.Write(cmd.OutOrStdout(), format, r); err != nil {
+		return err
+	}
+
+	errors := 0
+	for _, f := range r.Findings {
+		if f.Severity == report.SeverityError {
+			errors++
+		}
+	}
+	if errors > 0 {
+		return fmt.Errorf("%d %s errors", errors, r.Tool)
+	}
+	return nil
+}
@@ -43,9 +43,13 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 
+	rootCmd.SilenceUsage = true
+
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.bravewaldo.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose mode")
 	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "json or text (default is text)")
+	rootCmd.PersistentFlags().String("url-map", "", "YAML file mapping URLs to friendly names")
+	rootCmd.PersistentFlags().String("line-endings", "auto", "line endings of written markdown: auto keeps each file's own, lf or crlf")
 
 	if err := viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose")); err != nil {
 		fmt.Printf("Error binding verbose flag: %v\n", err)
@@ -55,6 +59,14 @@ func init() {
 		fmt.Printf("Error binding log-format flag: %v\n", err)
 		os.Exit(1)
 	}
+	if err := viper.BindPFlag("url-map", rootCmd.PersistentFlags().Lookup("url-map")); err != nil {
+		fmt.Printf("Error binding url-map flag: %v\n", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("line-endings", rootCmd.PersistentFlags().Lookup("line-endings")); err != nil {
+		fmt.Printf("Error binding line-endings flag: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func initConfig() {
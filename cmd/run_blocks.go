@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/report"
+	"github.com/gkwa/bravewaldo/internal/runblocks"
+)
+
+// staleOutputRule names the finding --check reports for each exec block
+// whose recorded output is stale.
+const staleOutputRule = "stale-output"
+
+var runBlocksCheck bool
+
+var runBlocksCmd = &cobra.Command{
+	Use:   "run-blocks [file|dir...]",
+	Short: "Run shell code blocks and record their output in the document",
+	Long: `Run-blocks executes the fenced code blocks marked with exec, as in
+` + "```sh exec" + `, and writes their combined stdout and stderr into the block
+flagged output right after each one, adding "` + "```" + runblocks.DefaultOutputInfo + `" blocks
+where there are none. A non-zero exit status is recorded as the last output
+line. Blocks run with --shell in the directory of their document, or --dir,
+with --env added to the environment. A block can set its own dir=path and
+timeout=duration. The flags can also be set under run-blocks in
+.bravewaldo.yaml. With --check nothing is written and the command fails when
+any recorded output is stale, reporting the stale blocks in the --format
+chosen, like lint.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if err := report.ValidateFormat(format); err != nil {
+			return err
+		}
+
+		opts := runblocks.Options{
+			Shell:   strings.Fields(viper.GetString("run-blocks.shell")),
+			Dir:     viper.GetString("run-blocks.dir"),
+			Env:     viper.GetStringSlice("run-blocks.env"),
+			Timeout: viper.GetDuration("run-blocks.timeout"),
+		}
+		ending, err := lineEnding()
+		if err != nil {
+			return err
+		}
+		opts.LineEnding = ending
+
+		paths, err := files.Markdown(defaultArgs(cmd, args))
+		if err != nil {
+			return err
+		}
+		paths, err = gitFilter(cmd, paths)
+		if err != nil {
+			return err
+		}
+
+		r := report.Report{
+			Tool:  "run-blocks",
+			Rules: []report.Rule{{ID: staleOutputRule, Description: "Recorded output should match what the exec block prints"}},
+			Paths: paths,
+		}
+		for _, path := range paths {
+			result, err := runblocks.Run(cmd.Context(), path, opts)
+			if err != nil {
+				return err
+			}
+			if runBlocksCheck {
+				for _, line := range result.Stale {
+					r.Findings = append(r.Findings, report.Finding{
+						Rule:     staleOutputRule,
+						Severity: report.SeverityError,
+						Path:     path,
+						Line:     line,
+						Column:   1,
+						Message:  "recorded output is stale",
+					})
+				}
+				continue
+			}
+			if len(result.Stale) > 0 {
+				if err := os.WriteFile(path, result.After, 0o644); err != nil {
+					return fmt.Errorf("failed to write %s: %w", path, err)
+				}
+				LoggerFrom(cmd.Context()).Info("Updated output", "path", path, "blocks", len(result.Stale))
+			}
+		}
+		if !runBlocksCheck {
+			return nil
+		}
+		return writeReport(cmd, r)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runBlocksCmd)
+	addGitFlags(runBlocksCmd)
+
+	runBlocksCmd.Flags().BoolVar(&runBlocksCheck, "check", false, "fail when recorded output is stale instead of updating it")
+	addFormatFlag(runBlocksCmd)
+	runBlocksCmd.Flags().String("shell", "sh -c", "command and arguments that run a block's content")
+	runBlocksCmd.Flags().String("dir", "", "working directory for blocks (default is the document's directory)")
+	runBlocksCmd.Flags().StringArray("env", nil, "KEY=VALUE to add to the environment of blocks, repeatable")
+	runBlocksCmd.Flags().Duration("timeout", 30*time.Second, "time limit for each block, 0 for none")
+
+	for _, name := range []string{"shell", "dir", "env", "timeout"} {
+		if err := viper.BindPFlag("run-blocks."+name, runBlocksCmd.Flags().Lookup(name)); err != nil {
+			fmt.Printf("Error binding %s flag: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+}
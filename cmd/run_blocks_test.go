@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunBlocksCheckFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	source := "# Doc\n\n```sh exec\necho fresh\n```\n\n```text output\nstale\n```\n"
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		runBlocksCheck = false
+		if err := runBlocksCmd.Flags().Set("format", "text"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"run-blocks", "--check", "--format", "github", path})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("run-blocks --check passed with stale output")
+	}
+	want := "::error file=" + path + ",line=3,col=1,title=stale-output::recorded output is stale\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if got, _ := os.ReadFile(path); string(got) != source {
+		t.Errorf("--check changed the document:\n%s", got)
+	}
+}
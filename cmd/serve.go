@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/watch"
+	"github.com/gkwa/bravewaldo/serve"
+)
+
+var serveAddr string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [dir]",
+	Short: "Preview a directory of markdown as HTML with live reload",
+	Long: `Serve renders markdown files to HTML on request, using the goldmark setup
+from core1 and the URL rewriting from core10. Other files next to the docs
+are served as static assets, and open pages reload when any of them
+change. Dotfiles are neither served nor watched.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := LoggerFrom(cmd.Context())
+
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+
+		srv := serve.New(logger, serve.Options{
+			Root:   root,
+			URLMap: loadURLMap,
+		})
+
+		// Pages also reload when stylesheets, images and other assets
+		// change.
+		options := watchOptions()
+		options.AllFiles = true
+		w, err := watch.New(logger, []string{root}, options)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		go func() {
+			if err := w.Run(ctx, srv.Notify); err != nil {
+				logger.Error(err, "Watcher stopped")
+			}
+		}()
+
+		httpServer := &http.Server{
+			Addr:              serveAddr,
+			Handler:           srv,
+			ReadHeaderTimeout: 10 * time.Second,
+			// Event streams end with ctx, so shutdown does not wait on them.
+			BaseContext: func(net.Listener) context.Context { return ctx },
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := httpServer.Shutdown(shutdownCtx); err != nil {
+				logger.Error(err, "Failed to shut down server")
+			}
+		}()
+
+		logger.Info("Serving", "root", root, "addr", serveAddr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "localhost:8080", "address to listen on")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/refactor"
+)
+
+var (
+	splitLevel  int
+	splitDir    string
+	splitDryRun bool
+)
+
+var splitCmd = &cobra.Command{
+	Use:   "split <file>",
+	Short: "Split a document into one file per heading",
+	Long: `Split writes each top-level section of file under a heading of --level to
+its own file, named after the heading's ID, in --dir (the file name without
+its extension by default). Sections are promoted to start with a level 1
+heading and keep the front matter. The file becomes an index that keeps the
+rest of the document and lists links to the sections where they were.
+#anchor links follow their headings into the new files and other relative
+links are rebased. With --dry-run the changes are printed as a unified diff
+and nothing is written.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, err := refactor.Split(args[0], splitLevel, splitDir)
+		if err != nil {
+			return err
+		}
+		if err := planLineEndings(plan); err != nil {
+			return err
+		}
+		if splitDryRun {
+			return plan.Diff(cmd.OutOrStdout())
+		}
+		if err := plan.Apply(); err != nil {
+			return err
+		}
+		LoggerFrom(cmd.Context()).Info("Split document", "path", args[0], "files", len(plan.Changes))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(splitCmd)
+
+	splitCmd.Flags().IntVar(&splitLevel, "level", 2, "heading level to split at")
+	splitCmd.Flags().StringVar(&splitDir, "dir", "", "directory for the section files")
+	splitCmd.Flags().BoolVarP(&splitDryRun, "dry-run", "n", false, "print a diff of the changes instead of applying them")
+}
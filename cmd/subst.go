@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gkwa/bravewaldo/internal/vars"
+)
+
+var (
+	substCode   bool
+	substStrict bool
+	substWrite  bool
+)
+
+var substCmd = &cobra.Command{
+	Use:   "subst [file|dir...]",
+	Short: "Replace {{ .name }} placeholders with variable values",
+	Long: `Subst replaces {{ .name }} placeholders in text, link destinations and
+everything else outside code with values from the document's front matter,
+the vars section of .bravewaldo.yaml and environment variables starting with
+--env-prefix, in that order of precedence. Nested values are reached with
+dots, as in {{ .site.url }}. Code spans and code blocks are left alone unless
+--code is given. Placeholders without a value are kept and reported, and
+fail the command with --strict. The result is printed unless --write
+updates the files in place.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var result *vars.Result
+			output, err := processLineEndings(source, func(normalized []byte) []byte {
+				result = vars.Substitute(normalized, varsOptions(substCode))
+				return result.Output
+			})
+			if err != nil {
+				return err
+			}
+			if len(result.Missing) > 0 {
+				if substStrict {
+					return fmt.Errorf("no value for %s", strings.Join(result.Missing, ", "))
+				}
+				LoggerFrom(cmd.Context()).Info("No value for placeholders", "path", path, "names", result.Missing)
+			}
+			if !substWrite {
+				_, err := cmd.OutOrStdout().Write(output)
+				return err
+			}
+			if string(output) == string(source) {
+				return nil
+			}
+			return os.WriteFile(path, output, 0o644)
+		})
+	},
+}
+
+// varsOptions returns the substitution options from the config file.
+func varsOptions(code bool) vars.Options {
+	return vars.Options{
+		Vars:      viper.GetStringMap("vars"),
+		EnvPrefix: viper.GetString("vars-env-prefix"),
+		Code:      code,
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(substCmd)
+	addWatchFlag(substCmd)
+
+	substCmd.Flags().BoolVar(&substCode, "code", false, "also substitute in code spans and code blocks")
+	substCmd.Flags().BoolVar(&substStrict, "strict", false, "fail when a placeholder has no value")
+	substCmd.Flags().BoolVarP(&substWrite, "write", "w", false, "write the result back to the files")
+	substCmd.Flags().String("env-prefix", vars.DefaultEnvPrefix, "prefix of environment variables that provide values")
+
+	if err := viper.BindPFlag("vars-env-prefix", substCmd.Flags().Lookup("env-prefix")); err != nil {
+		fmt.Printf("Error binding env-prefix flag: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/table"
+)
+
+var (
+	tableWrite   bool
+	tableFrom    string
+	tableTo      string
+	tableIndex   int
+	tableHeading string
+)
+
+var tableCmd = &cobra.Command{
+	Use:   "table",
+	Short: "Format markdown tables and convert them to and from CSV, TSV and JSON",
+}
+
+var tableFormatCmd = &cobra.Command{
+	Use:   "format [file|dir...]",
+	Short: "Align the pipes of the tables in markdown files",
+	Long: `Format lays out every GFM table so its pipes line up, counting East Asian
+wide characters as two columns, and rewrites delimiter rows as dashes with
+colons only for aligned columns. Nothing outside the tables changes. The
+result is printed unless --write updates the files in place.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			formatted, err := processLineEndings(source, table.Format)
+			if err != nil {
+				return err
+			}
+			if !tableWrite {
+				_, err := cmd.OutOrStdout().Write(formatted)
+				return err
+			}
+			if bytes.Equal(source, formatted) {
+				return nil
+			}
+			return os.WriteFile(path, formatted, 0o644)
+		})
+	},
+}
+
+var tableFromCmd = &cobra.Command{
+	Use:   "from [file]",
+	Short: "Convert CSV, TSV or a JSON array to a markdown table",
+	Long: `From reads CSV, TSV or a JSON array of objects or arrays from file, or from
+stdin when file is missing or "-", and prints it as an aligned markdown
+table. The first record, or the object keys, become the header, and columns
+of numbers are right aligned. The input format follows the file extension
+unless --from is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "-"
+		if len(args) > 0 {
+			path = args[0]
+		}
+		format := tableFrom
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		}
+
+		var in io.Reader = cmd.InOrStdin()
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		var t *table.Table
+		var err error
+		switch format {
+		case "csv":
+			t, err = table.ReadCSV(in, ',')
+		case "tsv":
+			t, err = table.ReadCSV(in, '\t')
+		case "json":
+			t, err = table.ReadJSON(in)
+		case "":
+			return fmt.Errorf("--from is required when reading stdin")
+		default:
+			return fmt.Errorf("unknown format %q, expected csv, tsv or json", format)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		_, err = io.WriteString(cmd.OutOrStdout(), t.Markdown())
+		return err
+	},
+}
+
+var tableExtractCmd = &cobra.Command{
+	Use:   "extract <file>",
+	Short: "Print a table of a markdown file as CSV, TSV or JSON",
+	Long: `Extract prints one table of a markdown file as CSV, TSV or a JSON array of
+objects keyed by the header. --heading keeps the tables whose nearest
+preceding heading has that text, ignoring case, and --index picks one of
+the remaining tables counting from 1.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+		var tables []*table.Table
+		for _, t := range table.Find(doc, source) {
+			if tableHeading == "" || strings.EqualFold(strings.TrimSpace(tableHeading), t.Heading) {
+				tables = append(tables, t)
+			}
+		}
+		if tableIndex < 1 || tableIndex > len(tables) {
+			where := ""
+			if tableHeading != "" {
+				where = fmt.Sprintf(" under heading %q", tableHeading)
+			}
+			return fmt.Errorf("%s has %d tables%s, cannot pick table %d", args[0], len(tables), where, tableIndex)
+		}
+
+		t := tables[tableIndex-1]
+		switch tableTo {
+		case "csv":
+			return t.WriteCSV(cmd.OutOrStdout(), ',')
+		case "tsv":
+			return t.WriteCSV(cmd.OutOrStdout(), '\t')
+		case "json":
+			return t.WriteJSON(cmd.OutOrStdout())
+		}
+		return fmt.Errorf("unknown format %q, expected csv, tsv or json", tableTo)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tableCmd)
+	tableCmd.AddCommand(tableFormatCmd, tableFromCmd, tableExtractCmd)
+
+	addWatchFlag(tableFormatCmd)
+	tableFormatCmd.Flags().BoolVarP(&tableWrite, "write", "w", false, "write the formatted tables back to the files")
+
+	tableFromCmd.Flags().StringVar(&tableFrom, "from", "", "input format: csv, tsv or json")
+
+	tableExtractCmd.Flags().StringVar(&tableTo, "to", "csv", "output format: csv, tsv or json")
+	tableExtractCmd.Flags().IntVar(&tableIndex, "index", 1, "which of the matching tables to print, counting from 1")
+	tableExtractCmd.Flags().StringVar(&tableHeading, "heading", "", "only consider tables under the heading with this text")
+}
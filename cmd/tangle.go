@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/tangle"
+)
+
+var (
+	tangleOpts   tangle.Options
+	tangleDryRun bool
+)
+
+var tangleCmd = &cobra.Command{
+	Use:   "tangle [file|dir...]",
+	Short: "Write fenced code blocks out to source files",
+	Long: `Tangle writes the contents of fenced code blocks to the files named by their
+info strings, as in ` + "```go file=main.go" + `. Blocks with the same target are
+concatenated in document order. --lang limits tangling to some languages and
+also picks up their blocks without a file attribute, which go to --output or
+to a file named after the document. Targets are relative to the document
+unless --dir is given. --line-directives points each block back at its
+markdown line, with //line for Go and #line for C.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := files.Markdown(defaultArgs(cmd, args))
+		if err != nil {
+			return err
+		}
+		paths, err = gitFilter(cmd, paths)
+		if err != nil {
+			return err
+		}
+
+		ending, err := lineEnding()
+		if err != nil {
+			return err
+		}
+		opts := tangleOpts
+		opts.LineEnding = ending
+		tangled, err := tangle.Tangle(paths, opts)
+		if err != nil {
+			return err
+		}
+		if tangleDryRun {
+			for _, f := range tangled {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s (%d blocks, %d bytes)\n", f.Path, f.Blocks, len(f.Content))
+			}
+			return nil
+		}
+		if err := tangle.Write(tangled); err != nil {
+			return err
+		}
+		LoggerFrom(cmd.Context()).Info("Tangled", "files", len(tangled))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tangleCmd)
+	addGitFlags(tangleCmd)
+
+	tangleCmd.Flags().StringSliceVar(&tangleOpts.Languages, "lang", nil, "only tangle blocks of these languages, including those without a file attribute")
+	tangleCmd.Flags().StringVarP(&tangleOpts.Output, "output", "o", "", "target for --lang blocks without a file attribute")
+	tangleCmd.Flags().StringVar(&tangleOpts.Dir, "dir", "", "directory targets are relative to instead of their document's")
+	tangleCmd.Flags().BoolVar(&tangleOpts.LineDirectives, "line-directives", false, "point each block back at its markdown line")
+	tangleCmd.Flags().BoolVarP(&tangleDryRun, "dry-run", "n", false, "list the files that would be written")
+}
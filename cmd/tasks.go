@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/internal/tasks"
+)
+
+var (
+	tasksFormat string
+	tasksOutput string
+	tasksOpen   bool
+	tasksOwner  string
+)
+
+var tasksCmd = &cobra.Command{
+	Use:   "tasks [file|dir...]",
+	Short: "List the task list items of documents",
+	Long: `Tasks collects the GFM task list items, - [ ] and - [x], of the markdown
+files with their state, file and line, the headings they are under and the
+@owner and due:YYYY-MM-DD tokens in their text. Each task has an ID derived
+from its file and text, which toggling does not change. The tasks are written
+as JSON or as a markdown summary with completion percentages per file and
+overdue tasks marked. --open and --owner narrow the list.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(tasks.Formats, tasksFormat) {
+			return fmt.Errorf("unknown format %q, expected one of %s", tasksFormat, strings.Join(tasks.Formats, ", "))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := gitFilter(cmd, defaultArgs(cmd, args))
+		if err != nil {
+			return err
+		}
+		all, err := tasks.Collect(paths)
+		if err != nil {
+			return err
+		}
+		selected := slices.DeleteFunc(all, func(t tasks.Task) bool {
+			return (tasksOpen && t.Done) || (tasksOwner != "" && !slices.Contains(t.Owners, tasksOwner))
+		})
+		return writeOutput(cmd, tasksOutput, func(w io.Writer) error {
+			return tasks.Write(w, tasksFormat, selected, time.Now())
+		})
+	},
+}
+
+var tasksToggleCmd = &cobra.Command{
+	Use:   "toggle <id>...",
+	Short: "Check or uncheck tasks by ID",
+	Long: `Toggle flips the state of the tasks with the given IDs, as listed by tasks,
+or written as path:line, among the markdown files in --in. Only the checkbox
+character of each task changes.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := cmd.Flags().GetStringArray("in")
+		if err != nil {
+			return err
+		}
+		if len(in) == 0 {
+			in = []string{defaultInput}
+		}
+		toggled, err := tasks.Toggle(in, args)
+		if err != nil {
+			return err
+		}
+		converted := map[string]bool{}
+		for _, t := range toggled {
+			LoggerFrom(cmd.Context()).Info("Toggled task", "id", t.ID, "path", t.Path, "line", t.Line, "done", t.Done)
+			if !converted[t.Path] {
+				converted[t.Path] = true
+				if err := convertLineEndings(filepath.FromSlash(t.Path)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tasksCmd)
+	tasksCmd.AddCommand(tasksToggleCmd)
+	addGitFlags(tasksCmd)
+
+	tasksCmd.Flags().StringVar(&tasksFormat, "format", tasks.FormatMarkdown, "output format: "+strings.Join(tasks.Formats, ", "))
+	tasksCmd.Flags().StringVarP(&tasksOutput, "output", "o", "-", "file to write the tasks to, - for stdout")
+	tasksCmd.Flags().BoolVar(&tasksOpen, "open", false, "only list open tasks")
+	tasksCmd.Flags().StringVar(&tasksOwner, "owner", "", "only list tasks with this @owner, without the @")
+
+	tasksToggleCmd.Flags().StringArray("in", nil, "markdown files or directories to look for the tasks in (default "+defaultInput+")")
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/bravewaldo/core11"
+)
+
+var (
+	reverse          bool
+	unrewriteOptions core11.UnrewriteOptions
+)
+
+func addReverseFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&reverse, "reverse", false, "turn links named by the URL map back into bare URLs")
+	cmd.Flags().BoolVar(&unrewriteOptions.All, "all", false, "with --reverse, replace every link to an absolute URL, even with custom text")
+	cmd.Flags().BoolVar(&unrewriteOptions.AutoLink, "autolink", false, "with --reverse, write <url> instead of the bare URL")
+}
+
+// runUnrewrite handles --reverse for the rewriting commands. Without args it
+// reads the default input and writes testdata/output.md like their Main
+// functions do; otherwise files are updated in place.
+func runUnrewrite(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && !gitSelection(cmd) {
+		return runInputs(cmd, []string{defaultInput}, func(path string) error {
+			urlMap, err := loadURLMap()
+			if err != nil {
+				return err
+			}
+			if err := core11.UnrewriteFile(path, defaultOutput, urlMap, unrewriteOptions); err != nil {
+				return err
+			}
+			return convertLineEndings(defaultOutput)
+		})
+	}
+	return runInputs(cmd, defaultArgs(cmd, args), func(path string) error {
+		urlMap, err := loadURLMap()
+		if err != nil {
+			return err
+		}
+		if err := core11.UnrewriteFile(path, path, urlMap, unrewriteOptions); err != nil {
+			return err
+		}
+		return convertLineEndings(path)
+	})
+}
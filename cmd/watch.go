@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/urlmap"
+	"github.com/gkwa/bravewaldo/internal/watch"
+)
+
+// defaultInput and defaultOutput are the files the commands read and
+// write without file arguments, like the Main functions of the cores.
+const (
+	defaultInput  = "testdata/input.md"
+	defaultOutput = "testdata/output.md"
+)
+
+// addWatchFlag adds --watch, and the git flags that select inputs, to a
+// command that processes its inputs with runInputs.
+func addWatchFlag(cmd *cobra.Command) {
+	addDefaultInputWatchFlag(cmd)
+	addGitFlags(cmd)
+}
+
+// addDefaultInputWatchFlag adds only --watch, for commands that always
+// process defaultInput.
+func addDefaultInputWatchFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("watch", false, "reprocess files when they, the config file or the URL map file change")
+}
+
+// runInputs calls process for every markdown file named by paths and, when
+// --watch is set, again for each file that changes afterwards. The git
+// flags are applied again on each change, so a file is only reprocessed
+// while it is still staged or changed.
+func runInputs(cmd *cobra.Command, paths []string, process func(path string) error) error {
+	logger := LoggerFrom(cmd.Context())
+
+	inputs, err := files.Markdown(paths)
+	if err != nil {
+		return err
+	}
+	inputs, err = gitFilter(cmd, inputs)
+	if err != nil {
+		return err
+	}
+	for _, path := range inputs {
+		logger.V(1).Info("Processing", "path", path)
+		if err := process(path); err != nil {
+			return fmt.Errorf("failed to process %s: %w", path, err)
+		}
+	}
+
+	watchEnabled, err := cmd.Flags().GetBool("watch")
+	if err != nil || !watchEnabled {
+		return nil
+	}
+
+	w, err := watch.New(logger, paths, watchOptions())
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	return w.Run(ctx, func(path string) error {
+		selected, err := gitFilter(cmd, []string{path})
+		if err != nil || len(selected) == 0 {
+			return err
+		}
+		return process(path)
+	})
+}
+
+// watchOptions makes watchers reprocess everything when the config file or
+// the URL map file changes.
+func watchOptions() watch.Options {
+	return watch.Options{
+		Extra: []string{viper.ConfigFileUsed(), viper.GetString("url-map")},
+		Reload: func() error {
+			if viper.ConfigFileUsed() == "" {
+				return nil
+			}
+			return viper.ReadInConfig()
+		},
+	}
+}
+
+func loadURLMap() (map[string]string, error) {
+	path := viper.GetString("url-map")
+	if path == "" {
+		return urlmap.Default(), nil
+	}
+	return urlmap.Load(path)
+}
@@ -52,6 +52,18 @@ func (r URLWrapperNodeRenderer) renderAutoLink(w util.BufWriter, source []byte,
 	return ast.WalkContinue, nil
 }
 
+// NewGoldmark returns a goldmark instance with the extensions and parser
+// options used throughout this package. Without a renderer option it
+// renders HTML.
+func NewGoldmark(options ...goldmark.Option) goldmark.Markdown {
+	return goldmark.New(append([]goldmark.Option{
+		goldmark.WithExtensions(extension.GFM, extension.DefinitionList, meta.Meta),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+		),
+	}, options...)...)
+}
+
 func Example(logger logr.Logger) {
 	logger.V(1).Info("Debug: Entering Example function")
 
@@ -62,13 +74,7 @@ func Example(logger logr.Logger) {
 	}
 
 	logger.V(1).Info("Creating new Goldmark instance")
-	md := goldmark.New(
-		goldmark.WithRenderer(NewURLWrapperRenderer(logger)),
-		goldmark.WithExtensions(extension.GFM, extension.DefinitionList, meta.Meta),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
-	)
+	md := NewGoldmark(goldmark.WithRenderer(NewURLWrapperRenderer(logger)))
 	doc := md.Parser().Parse(text.NewReader(source))
 
 	initialAST, err := dumpAST(doc)
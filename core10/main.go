@@ -14,6 +14,8 @@ import (
 	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
+
+	"github.com/gkwa/bravewaldo/internal/eol"
 )
 
 var (
@@ -21,30 +23,23 @@ var (
 	outputFilename = "testdata/output.md"
 )
 
-var urlMap = map[string]string{
-	"https://example.com":          "sample website",
-	"https://google.com":           "search engine",
-	"http://test.org":              "testing site",
-	"https://github.com/user/repo": "code repository",
-}
-
-func newURLRewriteRenderer(logger logr.Logger) renderer.Renderer {
+func newURLRewriteRenderer(logger logr.Logger, urlMap map[string]string) renderer.Renderer {
 	logger.V(1).Info("Creating new URLRewriteRenderer")
 	r := markdown.NewRenderer()
 	r.AddOptions(renderer.WithNodeRenderers(
-		util.Prioritized(urlRewriteNodeRenderer{logger: logger}, 1),
+		util.Prioritized(urlRewriteNodeRenderer{logger: logger, urlMap: urlMap}, 1),
 	))
 	return r
 }
 
 type urlRewriteNodeRenderer struct {
 	logger logr.Logger
+	urlMap map[string]string
 }
 
 func (r urlRewriteNodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 	r.logger.V(1).Info("Registering renderAutoLink function")
 	reg.Register(ast.KindAutoLink, r.renderAutoLink)
-	reg.Register(ast.KindText, r.renderText)
 }
 
 func (r urlRewriteNodeRenderer) renderAutoLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
@@ -53,7 +48,7 @@ func (r urlRewriteNodeRenderer) renderAutoLink(w util.BufWriter, source []byte,
 		n := node.(*ast.AutoLink)
 		url := string(n.URL(source))
 		r.logger.V(1).Info("Processing URL", "url", url)
-		if value, ok := urlMap[url]; ok {
+		if value, ok := r.urlMap[url]; ok {
 			r.logger.V(1).Info("Rewriting AutoLink", "url", url, "value", value)
 			fmt.Fprintf(w, "[%s](%s)", value, url)
 			return ast.WalkSkipChildren, nil
@@ -63,28 +58,59 @@ func (r urlRewriteNodeRenderer) renderAutoLink(w util.BufWriter, source []byte,
 	return ast.WalkContinue, nil
 }
 
-func (r urlRewriteNodeRenderer) renderText(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
-	r.logger.V(1).Info("Entering renderText")
-	if entering {
-		n := node.(*ast.Text)
-		segment := n.Segment
-		value := segment.Value(source)
-		r.logger.V(1).Info("Processing Text", "value", string(value))
+// RewriteAutoLinks replaces the autolinks in doc whose URL is in urlMap
+// with links that use the friendly name as their text. Unlike the node
+// renderer above it changes the AST, so it works with any renderer.
+func RewriteAutoLinks(doc ast.Node, source []byte, urlMap map[string]string) error {
+	var autoLinks []*ast.AutoLink
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			if autoLink, ok := n.(*ast.AutoLink); ok {
+				autoLinks = append(autoLinks, autoLink)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return err
 	}
-	return ast.WalkContinue, nil
+
+	for _, autoLink := range autoLinks {
+		url := string(autoLink.URL(source))
+		value, ok := urlMap[url]
+		if !ok {
+			continue
+		}
+		link := ast.NewLink()
+		link.Destination = []byte(url)
+		link.AppendChild(link, ast.NewString([]byte(value)))
+		autoLink.Parent().ReplaceChild(autoLink.Parent(), autoLink, link)
+	}
+	return nil
 }
 
-func Main(logger logr.Logger) {
+func Main(logger logr.Logger, urlMap map[string]string) {
 	logger.V(1).Info("Entering Main function")
-	source, err := os.ReadFile(inputFilename)
+	if err := RewriteFile(logger, inputFilename, outputFilename, urlMap); err != nil {
+		log.Fatal(err)
+	}
+	logger.V(1).Info("Main function completed successfully")
+}
+
+// RewriteFile rewrites the autolinks in input whose URL is in urlMap and
+// writes the result to output. input and output may be the same file.
+// The line endings, byte order mark and final newline of input are kept.
+func RewriteFile(logger logr.Logger, input, output string, urlMap map[string]string) error {
+	source, err := os.ReadFile(input)
 	if err != nil {
 		logger.Error(err, "Error reading input file")
-		log.Fatalf("Error reading input file: %v", err)
+		return fmt.Errorf("error reading input file: %w", err)
 	}
+	source, style := eol.Normalize(source)
 
 	logger.V(1).Info("Creating new Goldmark instance")
 	md := goldmark.New(
-		goldmark.WithRenderer(newURLRewriteRenderer(logger)),
+		goldmark.WithRenderer(newURLRewriteRenderer(logger, urlMap)),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
 		),
@@ -108,14 +134,14 @@ func Main(logger logr.Logger) {
 	logger.V(1).Info("Rendering markdown")
 	if err := md.Renderer().Render(&buf, source, doc); err != nil {
 		logger.Error(err, "Error rendering markdown")
-		log.Fatalf("Error rendering markdown: %v", err)
+		return fmt.Errorf("error rendering markdown: %w", err)
 	}
 
-	logger.V(1).Info("Writing output file")
-	if err := os.WriteFile(outputFilename, buf.Bytes(), 0o644); err != nil {
+	logger.V(1).Info("Writing output file", "path", output)
+	if err := os.WriteFile(output, style.Apply(buf.Bytes()), 0o644); err != nil {
 		logger.Error(err, "Error writing output file")
-		log.Fatalf("Error writing output file: %v", err)
+		return fmt.Errorf("error writing output file: %w", err)
 	}
 
-	logger.V(1).Info("Main function completed successfully")
+	return nil
 }
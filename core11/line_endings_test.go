@@ -0,0 +1,49 @@
+package core11
+
+import "testing"
+
+func TestProcessMarkdownLineEndings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		options  ProcessOptions
+	}{
+		{
+			name:     "crlf",
+			input:    "Also https://example.com\r\nand more\r\n",
+			expected: "Also [sample website](https://example.com)\r\nand more\r\n",
+		},
+		{
+			name:     "no final newline",
+			input:    "Also https://example.com\nand more",
+			expected: "Also [sample website](https://example.com)\nand more",
+		},
+		{
+			name:     "byte order mark",
+			input:    "\ufeffhttps://example.com\n",
+			expected: "\ufeff[sample website](https://example.com)\n",
+		},
+		{
+			name:     "mixed",
+			input:    "one\r\ntwo\nthree",
+			expected: "one\r\ntwo\nthree",
+		},
+		{
+			name:     "override",
+			input:    "\ufeffone\r\ntwo\nthree",
+			expected: "\ufeffone\ntwo\nthree",
+			options:  ProcessOptions{LineEnding: "\n"},
+		},
+		{
+			name:     "empty",
+			input:    "",
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testProcessMarkdown(t, tt.input, tt.expected, tt.options)
+		})
+	}
+}
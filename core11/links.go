@@ -0,0 +1,133 @@
+package core11
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gkwa/bravewaldo/internal/autolink"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// autolinkRegex matches the URL of an http or https CommonMark autolink,
+// <url>.
+var autolinkRegex = regexp.MustCompile(`(?i)^https?://[^\s<>]*$`)
+
+// rewriteLinks rewrites the inline links and bare URLs of line, which may
+// also be part of a longer line. Link destinations follow CommonMark, so
+// they may hold balanced parentheses, and bare URLs end where GFM ends
+// them. Code spans and backslash escapes are copied as they are.
+func rewriteLinks(line string, urlMap map[string]string, options ProcessOptions) string {
+	source := []byte(line)
+	urls := autolink.Find(autolink.Web, source)
+
+	var out strings.Builder
+	last := 0
+	emit := func(start, stop int, text string) {
+		out.WriteString(line[last:start])
+		out.WriteString(text)
+		last = stop
+	}
+
+	for pos := 0; pos < len(line); {
+		for len(urls) > 0 && urls[0].Start < pos {
+			urls = urls[1:]
+		}
+		switch c := line[pos]; {
+		case c == '\\':
+			pos += 2
+			continue
+		case c == '`':
+			if end := codeSpanEnd(line, pos); end > 0 {
+				pos = end
+			} else {
+				pos += backticks(line, pos)
+			}
+			continue
+		case c == '[':
+			if link, end, ok := parseInlineLink(line, source, pos); ok {
+				if link.Name != "" {
+					emit(pos, end, formatMarkdownLink(link, options.IncludeTitle))
+				}
+				pos = end
+				continue
+			}
+		case c == '<':
+			if gt := strings.IndexByte(line[pos:], '>'); gt > 0 && autolinkRegex.MatchString(line[pos+1:pos+gt]) {
+				if rewritten, ok := RewriteURL(line[pos+1:pos+gt], urlMap); ok {
+					emit(pos, pos+gt+1, rewritten)
+				}
+				pos += gt + 1
+				continue
+			}
+		case len(urls) > 0 && urls[0].Start == pos:
+			span := urls[0]
+			// The text or destination of a link whose text started on an
+			// earlier line.
+			inLink := !strings.Contains(line[:span.Start], "[") &&
+				(strings.HasPrefix(line[span.Stop:], "](") || strings.HasSuffix(line[:span.Start], "]("))
+			if rewritten, ok := RewriteURL(line[span.Start:span.Stop], urlMap); ok && !inLink {
+				emit(span.Start, span.Stop, rewritten)
+			}
+			pos = span.Stop
+			continue
+		}
+		pos++
+	}
+	out.WriteString(line[last:])
+	return out.String()
+}
+
+// codeSpanEnd returns the offset after the code span that starts with the
+// backtick run at start, or 0 when the run is not closed on the line.
+func codeSpanEnd(line string, start int) int {
+	n := backticks(line, start)
+	fence := line[start : start+n]
+	for i := start + n; i < len(line); {
+		j := strings.Index(line[i:], fence)
+		if j < 0 {
+			return 0
+		}
+		j += i
+		m := backticks(line, j)
+		if m == n {
+			return j + n
+		}
+		i = j + m
+	}
+	return 0
+}
+
+// backticks returns the length of the run of backticks at pos.
+func backticks(line string, pos int) int {
+	return len(line[pos:]) - len(strings.TrimLeft(line[pos:], "`"))
+}
+
+// parseInlineLink parses the inline link [text](destination "title") that
+// starts with the bracket at start, using the CommonMark scanner of the
+// mdast package, and returns it and the offset after it. Links that
+// ProcessMarkdown does not reformat, with whitespace after the opening
+// parenthesis, a parenthesized title or no destination, are returned
+// without a name.
+func parseInlineLink(line string, source []byte, start int) (MarkdownLink, int, bool) {
+	spans, ok := mdast.ScanInlineLink(source, start)
+	if !ok {
+		return MarkdownLink{}, 0, false
+	}
+	// The destination is kept as written, with any angle brackets.
+	destination := spans.Destination
+	if destination.Start > 0 && source[destination.Start-1] == '<' {
+		destination.Start--
+		destination.Stop++
+	}
+	link := MarkdownLink{
+		Name:  strings.TrimSpace(line[spans.Text.Start:spans.Text.Stop]),
+		URL:   line[destination.Start:destination.Stop],
+		Title: line[spans.Title.Start:spans.Title.Stop],
+	}
+	leading := destination.Start > spans.Text.Stop+2
+	parenthesized := spans.Title.Start > 0 && source[spans.Title.Start-1] == '('
+	if leading || parenthesized || link.URL == "" {
+		link.Name = ""
+	}
+	return link, spans.Full.Stop, true
+}
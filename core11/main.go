@@ -2,13 +2,16 @@ package core11
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"regexp"
 	"strings"
 )
 
+const bom = "\ufeff"
+
 type MarkdownLink struct {
 	Name  string
 	URL   string
@@ -17,65 +20,122 @@ type MarkdownLink struct {
 
 type ProcessOptions struct {
 	IncludeTitle bool
+	// LineEnding, when set, replaces the line ending of every line.
+	// Otherwise each line keeps its own.
+	LineEnding string
+	// MaxSize, when positive, is the most bytes of input that are read.
+	// Larger inputs fail with ErrTooLarge.
+	MaxSize int64
 }
 
-func ProcessMarkdown(input io.Reader, output io.Writer, urlMap map[string]string, options ProcessOptions) error {
-	scanner := bufio.NewScanner(input)
+// ErrTooLarge is returned for input over ProcessOptions.MaxSize.
+var ErrTooLarge = errors.New("input too large")
 
-	re := regexp.MustCompile(`\[([^\]]+)\]\s*\(([^)]+)\)|\bhttps?://\S+`)
+// chunkSize bounds the memory used for a line. Longer lines are processed
+// a chunk at a time, split after a space or tab so that the links and URLs
+// in them are kept whole.
+const chunkSize = 64 * 1024
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = re.ReplaceAllStringFunc(line, func(match string) string {
-			link := parseMarkdownLink(match)
-			if link.Name != "" && link.URL != "" {
-				return formatMarkdownLink(link, options.IncludeTitle)
+// ProcessMarkdown rewrites the links of input line by line, streaming it
+// to output. Line endings, a byte order mark and a missing final newline
+// are written as they were read. Memory use does not grow with the input
+// or the length of its lines; links and URLs that are themselves longer
+// than 64KB are copied unchanged.
+func ProcessMarkdown(input io.Reader, output io.Writer, urlMap map[string]string, options ProcessOptions) error {
+	if options.MaxSize > 0 {
+		input = &limitedReader{r: input, max: options.MaxSize}
+	}
+	reader := bufio.NewReaderSize(input, chunkSize)
+	w := bufio.NewWriter(output)
+
+	// pending holds the start of a line longer than the reader's buffer,
+	// and raw is set while copying a token too long to hold.
+	var pending []byte
+	raw := false
+	for first := true; ; first = false {
+		data, err := reader.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull && err != io.EOF {
+			if errors.Is(err, ErrTooLarge) {
+				return err
 			}
-
-			surroundingText := line
-			index := strings.Index(surroundingText, match)
-			if index > 0 && strings.HasSuffix(strings.TrimSpace(surroundingText[:index]), "[") &&
-				index+len(match) < len(surroundingText) && strings.HasPrefix(strings.TrimSpace(surroundingText[index+len(match):]), ")") {
-				return match
+			return fmt.Errorf("error reading input: %v", err)
+		}
+		if first && bytes.HasPrefix(data, []byte(bom)) {
+			w.WriteString(bom)
+			data = data[len(bom):]
+		}
+		if raw {
+			end := bytes.IndexAny(data, " \t\r\n")
+			if end < 0 {
+				end = len(data)
+			} else {
+				raw = false
 			}
+			w.Write(data[:end])
+			data = data[end:]
+		}
 
-			lowercaseURL := strings.ToLower(link.URL)
-			friendlyName, ok := urlMap[lowercaseURL]
-			if ok {
-				link.Name = friendlyName
-				return formatMarkdownLink(link, options.IncludeTitle)
+		if err == bufio.ErrBufferFull {
+			pending = append(pending, data...)
+			if len(pending) < chunkSize {
+				continue
 			}
-			return link.URL
-		})
+			cut := bytes.LastIndexAny(pending, " \t") + 1
+			if cut == 0 {
+				w.Write(pending)
+				pending = pending[:0]
+				raw = true
+				continue
+			}
+			w.WriteString(rewriteLinks(string(pending[:cut]), urlMap, options))
+			pending = append(pending[:0], pending[cut:]...)
+			continue
+		}
 
-		fmt.Fprintln(output, line)
+		line := string(pending) + string(data)
+		pending = pending[:0]
+		content := strings.TrimSuffix(line, "\n")
+		ending := line[len(content):]
+		if strings.HasSuffix(content, "\r") && ending != "" {
+			content, ending = content[:len(content)-1], "\r\n"
+		}
+		if options.LineEnding != "" && ending != "" {
+			ending = options.LineEnding
+		}
+		w.WriteString(rewriteLinks(content, urlMap, options))
+		w.WriteString(ending)
+		if err == io.EOF {
+			break
+		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading input: %v", err)
-	}
+	return w.Flush()
+}
 
-	return nil
+// limitedReader fails with ErrTooLarge once more than max bytes are read.
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
 }
 
-func parseMarkdownLink(text string) MarkdownLink {
-	re := regexp.MustCompile(`^\s*\[([^\]]+)\]\s*\(([^)\s]+)(?:\s+(?:"([^"]+)"|'([^']+)')?)?\s*\)\s*$`)
-	matches := re.FindStringSubmatch(text)
-	if len(matches) >= 3 {
-		link := MarkdownLink{
-			Name: strings.TrimSpace(matches[1]),
-			URL:  strings.TrimSpace(matches[2]),
-		}
-		if len(matches) >= 4 {
-			if matches[3] != "" {
-				link.Title = matches[3] // Double-quoted title
-			} else if matches[4] != "" {
-				link.Title = matches[4] // Single-quoted title
-			}
-		}
-		return link
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, l.max)
+	}
+	return n, err
+}
+
+// RewriteURL returns the markdown link that ProcessMarkdown substitutes for
+// a bare url, and whether urlMap has a friendly name for it.
+func RewriteURL(url string, urlMap map[string]string) (string, bool) {
+	friendlyName, ok := urlMap[strings.ToLower(url)]
+	if !ok {
+		return url, false
 	}
-	return MarkdownLink{URL: text}
+	return formatMarkdownLink(MarkdownLink{Name: friendlyName, URL: url}, false), true
 }
 
 func formatMarkdownLink(link MarkdownLink, includeTitle bool) string {
@@ -88,26 +148,48 @@ func formatMarkdownLink(link MarkdownLink, includeTitle bool) string {
 	return fmt.Sprintf("[%s](%s)", link.Name, link.URL)
 }
 
-func Main() error {
-	urlMap := map[string]string{
-		"https://example.com":          "sample website",
-		"https://google.com":           "search engine",
-		"http://test.org":              "testing site",
-		"https://github.com/user/repo": "code repository",
+// ProcessFile runs ProcessMarkdown over input and writes the result to
+// output. input and output may be the same file. The result is staged in
+// a temporary file rather than in memory, and output is left as it was
+// when processing fails.
+func ProcessFile(input, output string, urlMap map[string]string, options ProcessOptions) error {
+	in, err := os.Open(input)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %v", err)
+	}
+	defer in.Close()
+	if info, err := in.Stat(); err == nil && options.MaxSize > 0 && info.Size() > options.MaxSize {
+		return fmt.Errorf("%w: %s is %d bytes, over the limit of %d", ErrTooLarge, input, info.Size(), options.MaxSize)
 	}
 
-	inputFile, err := os.Open("testdata/input.md")
+	tmp, err := os.CreateTemp("", "bravewaldo-*.md")
 	if err != nil {
-		return fmt.Errorf("failed to open input file: %v", err)
+		return fmt.Errorf("failed to create temporary file: %v", err)
 	}
-	defer inputFile.Close()
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
 
-	outputFile, err := os.Create("testdata/output.md")
+	if err := ProcessMarkdown(in, tmp, urlMap, options); err != nil {
+		return fmt.Errorf("%s: %w", input, err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read temporary file: %v", err)
+	}
+
+	out, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %v", err)
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	if _, err := io.Copy(out, tmp); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write output file: %v", err)
 	}
-	defer outputFile.Close()
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
 
-	options := ProcessOptions{IncludeTitle: false}
-	return ProcessMarkdown(inputFile, outputFile, urlMap, options)
+func Main(urlMap map[string]string, options ProcessOptions) error {
+	return ProcessFile("testdata/input.md", "testdata/output.md", urlMap, options)
 }
@@ -0,0 +1,90 @@
+package core11
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessMarkdownLongLines(t *testing.T) {
+	words := strings.Repeat("word ", chunkSize/5-2)
+	dataURI := "![x](data:image/png;base64," + strings.Repeat("A", 3*chunkSize) + ")"
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "link across a chunk boundary",
+			input:    words + "see https://example.com now\n",
+			expected: words + "see [sample website](https://example.com) now\n",
+		},
+		{
+			name:     "many chunks",
+			input:    strings.Repeat("https://google.com ", 20000) + "\nhttps://example.com\n",
+			expected: strings.Repeat("[search engine](https://google.com) ", 20000) + "\n[sample website](https://example.com)\n",
+		},
+		{
+			name:     "token longer than a chunk",
+			input:    "before https://example.com " + dataURI + " after https://example.com\r\nnext",
+			expected: "before [sample website](https://example.com) " + dataURI + " after [sample website](https://example.com)\r\nnext",
+		},
+		{
+			name:     "line ending after a long token",
+			input:    dataURI + "\nhttps://example.com\n",
+			expected: dataURI + "\n[sample website](https://example.com)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := ProcessMarkdown(strings.NewReader(tt.input), &output, urlMap, ProcessOptions{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.String() != tt.expected {
+				t.Errorf("output mismatch: got %d bytes, want %d", output.Len(), len(tt.expected))
+			}
+		})
+	}
+}
+
+func TestProcessMarkdownMaxSize(t *testing.T) {
+	input := strings.Repeat("line\n", 1000)
+	var output bytes.Buffer
+	err := ProcessMarkdown(strings.NewReader(input), &output, urlMap, ProcessOptions{MaxSize: 4096})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("got %v, want ErrTooLarge", err)
+	}
+
+	output.Reset()
+	if err := ProcessMarkdown(strings.NewReader(input), &output, urlMap, ProcessOptions{MaxSize: int64(len(input))}); err != nil {
+		t.Fatalf("input at the limit: %v", err)
+	}
+}
+
+func TestProcessFileMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	input := "https://example.com\n" + strings.Repeat("text\n", 100)
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ProcessFile(path, path, urlMap, ProcessOptions{MaxSize: 100})
+	if !errors.Is(err, ErrTooLarge) || !strings.Contains(err.Error(), path) {
+		t.Fatalf("got %v, want ErrTooLarge naming %s", err, path)
+	}
+	if got, _ := os.ReadFile(path); string(got) != input {
+		t.Error("file changed after a failure")
+	}
+
+	if err := ProcessFile(path, path, urlMap, ProcessOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	if want := "[sample website](https://example.com)\n" + strings.Repeat("text\n", 100); string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
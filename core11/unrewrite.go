@@ -0,0 +1,101 @@
+package core11
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/autolink"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+type UnrewriteOptions struct {
+	// All replaces every inline link to an absolute URL, not only those
+	// whose text is the URL map name for their destination.
+	All bool
+	// AutoLink writes <url> instead of the bare url.
+	AutoLink bool
+}
+
+var absoluteURLRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*$`)
+
+// Unrewrite reverses ProcessMarkdown: inline links whose text matches the
+// URL map name of their destination become the bare destination again.
+// Where the bare URL would not be autolinked as it is, because of the text
+// around it, it is written as <url> instead. Links with other text, a
+// title or backslash escapes or entities in their destination, reference
+// links, images and relative links are left alone.
+func Unrewrite(source []byte, urlMap map[string]string, options UnrewriteOptions) []byte {
+	root := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+	var out bytes.Buffer
+	last := 0
+	for _, link := range mdast.Links(root, source) {
+		if _, ok := link.Node.(*ast.Link); !ok || link.Span.Start < last {
+			continue
+		}
+		spans, ok := mdast.InlineLinkSpans(source, link.Node)
+		if !ok {
+			continue
+		}
+		// Titles would be lost, and autolinks take escapes and entities
+		// literally.
+		destination := string(spans.Destination.Value(source))
+		if !absoluteURLRegex.MatchString(destination) || strings.Contains(destination, "\\") || html.UnescapeString(destination) != destination || spans.Title.Start > 0 {
+			continue
+		}
+		if name, ok := urlMap[strings.ToLower(destination)]; !options.All && (!ok || name != link.Label) {
+			continue
+		}
+
+		out.Write(source[last:link.Span.Start])
+		if options.AutoLink || !autolinks(source, link.Span, destination) {
+			fmt.Fprintf(&out, "<%s>", destination)
+		} else {
+			out.WriteString(destination)
+		}
+		last = link.Span.Stop
+	}
+	out.Write(source[last:])
+	return out.Bytes()
+}
+
+// autolinks reports whether destination, written in place of the link at
+// span, is autolinked as a bare URL. GFM only autolinks a URL at the
+// start of a line, after whitespace or after one of *, _, ~ and (, and
+// the URL must end where destination does rather than run on into the
+// text after it or give up trailing punctuation.
+func autolinks(source []byte, span mdast.Span, destination string) bool {
+	if span.Start > 0 && !strings.ContainsRune(" \t\n*_~(", rune(source[span.Start-1])) {
+		return false
+	}
+	end := span.Stop
+	if i := bytes.IndexByte(source[end:], '\n'); i >= 0 {
+		end += i
+	} else {
+		end = len(source)
+	}
+	urls := autolink.Find(autolink.Web, append([]byte(destination), source[span.Stop:end]...))
+	return len(urls) > 0 && urls[0] == mdast.Span{Start: 0, Stop: len(destination)}
+}
+
+// UnrewriteFile runs Unrewrite over input and writes the result to output.
+// input and output may be the same file.
+func UnrewriteFile(input, output string, urlMap map[string]string, options UnrewriteOptions) error {
+	source, err := os.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %v", err)
+	}
+
+	if err := os.WriteFile(output, Unrewrite(source, urlMap, options), 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
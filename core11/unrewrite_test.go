@@ -0,0 +1,90 @@
+package core11
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnrewrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		options  UnrewriteOptions
+		expected string
+	}{
+		{
+			name:     "map name",
+			input:    "See [sample website](https://example.com) and [search engine](https://Google.com).\n",
+			expected: "See https://example.com and https://Google.com.\n",
+		},
+		{
+			name:     "text after the link would extend the URL",
+			input:    "[sample website](https://example.com)foo and [sample website](https://example.com/a.)\n",
+			options:  UnrewriteOptions{All: true},
+			expected: "<https://example.com>foo and <https://example.com/a.>\n",
+		},
+		{
+			name:     "text before the link would suppress the autolink",
+			input:    "see[sample website](https://example.com) and ([sample website](https://example.com))\n",
+			expected: "see<https://example.com> and (https://example.com)\n",
+		},
+		{
+			name:     "schemes GFM does not autolink",
+			input:    "[Go](ftp://go.dev)\n",
+			options:  UnrewriteOptions{All: true},
+			expected: "<ftp://go.dev>\n",
+		},
+		{
+			name:     "titles, escapes and entities kept",
+			input:    "[search engine](https://Google.com \"google\") [sample website](https://example.com/\\_x) [sample website](https://example.com/?a&amp;b)\n",
+			options:  UnrewriteOptions{All: true},
+			expected: "[search engine](https://Google.com \"google\") [sample website](https://example.com/\\_x) [sample website](https://example.com/?a&amp;b)\n",
+		},
+		{
+			name:     "autolink",
+			input:    "See [sample website](https://example.com).\n",
+			options:  UnrewriteOptions{AutoLink: true},
+			expected: "See <https://example.com>.\n",
+		},
+		{
+			name:     "custom text kept",
+			input:    "See [our site](https://example.com) and [Go](https://go.dev).\n",
+			expected: "See [our site](https://example.com) and [Go](https://go.dev).\n",
+		},
+		{
+			name:     "all",
+			input:    "See [our site](https://example.com) and [Go](https://go.dev).\n",
+			options:  UnrewriteOptions{All: true, AutoLink: true},
+			expected: "See <https://example.com> and <https://go.dev>.\n",
+		},
+		{
+			name:     "relative links, images, references and code kept",
+			input:    "[docs](docs/guide.md) ![sample website](https://example.com) [sample website][ref] `[sample website](https://example.com)`\n\n[ref]: https://example.com\n",
+			options:  UnrewriteOptions{All: true},
+			expected: "[docs](docs/guide.md) ![sample website](https://example.com) [sample website][ref] `[sample website](https://example.com)`\n\n[ref]: https://example.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(Unrewrite([]byte(tt.input), urlMap, tt.options))
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnrewriteReversesProcessMarkdown(t *testing.T) {
+	input := "Visit https://example.com and http://test.org, or https://go.dev\n"
+	var rewritten bytes.Buffer
+	if err := ProcessMarkdown(strings.NewReader(input), &rewritten, urlMap, ProcessOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(Unrewrite(rewritten.Bytes(), urlMap, UnrewriteOptions{})); got != input {
+		t.Errorf("got %q, want %q", got, input)
+	}
+}
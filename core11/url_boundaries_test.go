@@ -0,0 +1,135 @@
+package core11
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestURLBoundaries(t *testing.T) {
+	names := map[string]string{
+		"https://en.wikipedia.org/wiki/go_(programming_language)": "Go",
+		"https://example.com":                 "example",
+		"https://example.com/a_b":             "a_b",
+		"https://example.com/search?q=1&lang": "search",
+	}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "balanced parentheses in a bare URL",
+			input:    "See https://en.wikipedia.org/wiki/Go_(programming_language) now",
+			expected: "See [Go](https://en.wikipedia.org/wiki/Go_(programming_language)) now",
+		},
+		{
+			name:     "bare URL in parentheses",
+			input:    "Go (https://en.wikipedia.org/wiki/Go_(programming_language)) is fun",
+			expected: "Go ([Go](https://en.wikipedia.org/wiki/Go_(programming_language))) is fun",
+		},
+		{
+			name:     "unbalanced closing parenthesis",
+			input:    "(see https://example.com)",
+			expected: "(see [example](https://example.com))",
+		},
+		{
+			name:     "trailing period",
+			input:    "Visit https://example.com.",
+			expected: "Visit [example](https://example.com).",
+		},
+		{
+			name:     "trailing comma and more",
+			input:    "https://example.com, https://example.com; https://example.com!",
+			expected: "[example](https://example.com), [example](https://example.com); [example](https://example.com)!",
+		},
+		{
+			name:     "trailing question mark and colon",
+			input:    "Is it https://example.com? Yes: https://example.com:",
+			expected: "Is it [example](https://example.com)? Yes: [example](https://example.com):",
+		},
+		{
+			name:     "trailing emphasis",
+			input:    "**https://example.com** and _https://example.com/a_b_",
+			expected: "**[example](https://example.com)** and _[a_b](https://example.com/a_b)_",
+		},
+		{
+			name:     "quoted",
+			input:    `"https://example.com" and 'https://example.com'`,
+			expected: `"[example](https://example.com)" and '[example](https://example.com)'`,
+		},
+		{
+			name:     "trailing entity reference",
+			input:    "https://example.com/search?q=1&lang&amp;",
+			expected: "[search](https://example.com/search?q=1&lang)&amp;",
+		},
+		{
+			name:     "autolink",
+			input:    "<https://example.com> and <https://example.org>",
+			expected: "[example](https://example.com) and <https://example.org>",
+		},
+		{
+			name:     "link destination with balanced parentheses",
+			input:    `[Go](https://en.wikipedia.org/wiki/Go_(programming_language) "Go") rocks`,
+			expected: "[Go](https://en.wikipedia.org/wiki/Go_(programming_language)) rocks",
+		},
+		{
+			name:     "link destination with escaped parenthesis",
+			input:    `[x](https://example.com/\)) and https://example.com`,
+			expected: `[x](https://example.com/\)) and [example](https://example.com)`,
+		},
+		{
+			name:     "link destination in angle brackets",
+			input:    "[x](<https://example.com/a b> 'T') done.",
+			expected: "[x](<https://example.com/a b>) done.",
+		},
+		{
+			name:     "title without whitespace before it",
+			input:    `[x](<https://example.com>"t")`,
+			expected: `[x]([example](https://example.com)"t")`,
+		},
+		{
+			name:     "link text with brackets and a URL",
+			input:    "[see [https://example.com]](https://example.com).",
+			expected: "[see [https://example.com]](https://example.com).",
+		},
+		{
+			name:     "not a link",
+			input:    "[x] (https://example.com)",
+			expected: "[x] ([example](https://example.com))",
+		},
+		{
+			name:     "code span",
+			input:    "`https://example.com` and ``[a](b \"t\")`` but https://example.com",
+			expected: "`https://example.com` and ``[a](b \"t\")`` but [example](https://example.com)",
+		},
+		{
+			name:     "escaped bracket",
+			input:    `\[x](https://example.com)`,
+			expected: `\[x]([example](https://example.com))`,
+		},
+		{
+			name:     "link continued from the previous line",
+			input:    "https://example.com](https://example.com)",
+			expected: "https://example.com](https://example.com)",
+		},
+		{
+			name:     "scheme-less host",
+			input:    "www.example.com and ftp://example.com",
+			expected: "www.example.com and ftp://example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := ProcessMarkdown(strings.NewReader(tt.input), &output, names, ProcessOptions{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, output.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
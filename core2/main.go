@@ -3,6 +3,7 @@ package core2
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -11,32 +12,60 @@ import (
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/extrender"
+	"github.com/gkwa/bravewaldo/internal/table"
 )
 
-func Main() {
+// Format converts markdown source to formatted markdown.
+// Line endings, byte order mark and final newline are kept as in source.
+func Format(source []byte) ([]byte, error) {
+	return eol.Process(source, "", format)
+}
+
+// format formats source with LF line endings and no byte order mark.
+func format(source []byte) ([]byte, error) {
 	// Create goldmark converter with markdown renderer object
 	// Can pass functional Options as arguments. This example converts headings to ATX style.
 	renderer := markdown.NewRenderer()
 	md := goldmark.New(
 		goldmark.WithRenderer(renderer),
-		goldmark.WithExtensions(extension.GFM, meta.Meta),
+		goldmark.WithExtensions(extension.GFM, extension.DefinitionList, extrender.Footnote, meta.Meta),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
 		),
 	)
+	// Lay out GFM tables, footnotes and definition lists as markdown
+	// instead of the extensions' HTML.
+	md.Renderer().AddOptions(table.NewNodeRenderer(), extrender.NewNodeRenderer())
 
 	// "Convert" markdown to formatted markdown
+	buf := bytes.Buffer{}
+	if err := md.Convert(source, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
+// FormatFile writes the formatted markdown of filename to w.
+func FormatFile(w io.Writer, filename string) error {
 	// Read input from file
-	source, err := os.ReadFile("testdata/input.md")
+	source, err := os.ReadFile(filename)
 	if err != nil {
-		log.Fatalf("Error reading input file: %v", err)
+		return fmt.Errorf("error reading input file: %w", err)
 	}
 
-	buf := bytes.Buffer{}
-	err = md.Convert(source, &buf)
+	output, err := Format(source)
 	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(w, string(output))
+	return err
+}
+
+func Main() {
+	if err := FormatFile(os.Stdout, "testdata/input.md"); err != nil {
 		log.Fatal(err)
 	}
-	fmt.Print(buf.String())
 }
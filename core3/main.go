@@ -3,21 +3,24 @@ package core3
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
 	markdown "github.com/teekennedy/goldmark-markdown"
 	"github.com/yuin/goldmark"
-)
 
-func Main() {
-	filename := "testdata/input.md"
+	"github.com/gkwa/bravewaldo/internal/eol"
+)
 
-	source, err := os.ReadFile(filename)
-	if err != nil {
-		log.Fatalf("Error reading file: %v", err)
-	}
+// Format converts markdown source to formatted markdown with ATX headings.
+// Line endings, byte order mark and final newline are kept as in source.
+func Format(source []byte) ([]byte, error) {
+	return eol.Process(source, "", format)
+}
 
+// format formats source with LF line endings and no byte order mark.
+func format(source []byte) ([]byte, error) {
 	// Create goldmark converter with markdown renderer object
 	// Can pass functional Options as arguments. This example converts headings to ATX style.
 	renderer := markdown.NewRenderer(markdown.WithHeadingStyle(markdown.HeadingStyleATX))
@@ -25,10 +28,31 @@ func Main() {
 
 	// "Convert" markdown to formatted markdown
 	buf := bytes.Buffer{}
-	err = md.Convert(source, &buf)
+	if err := md.Convert(source, &buf); err != nil {
+		return nil, fmt.Errorf("error converting markdown: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatFile writes the formatted markdown of filename to w.
+func FormatFile(w io.Writer, filename string) error {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	output, err := Format(source)
 	if err != nil {
-		log.Fatalf("Error converting markdown: %v", err)
+		return err
 	}
+	_, err = w.Write(output)
+	return err
+}
 
-	fmt.Println(buf.String())
+func Main() {
+	filename := "testdata/input.md"
+
+	if err := FormatFile(os.Stdout, filename); err != nil {
+		log.Fatal(err)
+	}
 }
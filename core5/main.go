@@ -2,13 +2,20 @@ package core5
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"os"
 	"regexp"
+	"strings"
 
 	markdown "github.com/teekennedy/goldmark-markdown"
 	"github.com/yuin/goldmark"
-	"mvdan.cc/xurls/v2"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/gkwa/bravewaldo/core11"
+	"github.com/gkwa/bravewaldo/internal/autolink"
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/mdast"
 )
 
 var (
@@ -16,35 +23,98 @@ var (
 	outputFilename = "testdata/output.md"
 )
 
-func processURLs(input []byte) []byte {
-	rxStrict := xurls.Strict()
-	mdLinkRegex := regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
-	protected := mdLinkRegex.ReplaceAllFunc(input, func(match []byte) []byte {
-		return bytes.Replace(match, []byte("("), []byte("(protected:"), 1)
-	})
-	processed := rxStrict.ReplaceAllFunc(protected, func(match []byte) []byte {
-		return match
-	})
-	return mdLinkRegex.ReplaceAllFunc(processed, func(match []byte) []byte {
-		return bytes.Replace(match, []byte("(protected:"), []byte("("), 1)
-	})
+type Options struct {
+	// URLMap gives friendly names to URLs. A bare URL with a name becomes
+	// [name](url) instead of <url>.
+	URLMap map[string]string
+	// Relaxed also matches scheme-less hosts that start with "www.". Like
+	// GFM autolinks, they are linked with an http:// destination.
+	Relaxed bool
+}
+
+// processURLs turns bare URLs in the text of input into autolinks, or into
+// links when opts.URLMap names them. URLs inside links, code spans, code
+// blocks and HTML are left alone because only plain text nodes are
+// searched.
+func processURLs(input []byte, opts Options) []byte {
+	rx := autolink.Strict
+	if opts.Relaxed {
+		rx = autolink.Relaxed
+	}
+
+	type edit struct {
+		start, stop int
+		text        string
+	}
+	var edits []edit
+	for _, run := range mdast.TextRuns(goldmark.New().Parser().Parse(text.NewReader(input))) {
+		segment := run.Value(input)
+		for _, span := range autolink.Find(rx, segment) {
+			url := string(span.Value(segment))
+			if replacement, ok := linkURL(url, opts); ok {
+				edits = append(edits, edit{run.Start + span.Start, run.Start + span.Stop, replacement})
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		out.Write(input[last:e.start])
+		out.WriteString(e.text)
+		last = e.stop
+	}
+	out.Write(input[last:])
+	return out.Bytes()
+}
+
+var schemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// linkURL returns the markdown for a bare url, or false when url should be
+// left as text.
+func linkURL(url string, opts Options) (string, bool) {
+	destination := url
+	if !schemeRegex.MatchString(url) {
+		if !strings.HasPrefix(strings.ToLower(url), "www.") {
+			return "", false
+		}
+		destination = "http://" + url
+	}
+
+	if rewritten, ok := core11.RewriteURL(destination, opts.URLMap); ok {
+		return rewritten, true
+	}
+	if destination != url {
+		return fmt.Sprintf("[%s](%s)", url, destination), true
+	}
+	return "<" + url + ">", true
+}
+
+func Main(opts Options) {
+	if err := ProcessFile(inputFilename, outputFilename, opts); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func Main() {
-	source, err := os.ReadFile(inputFilename)
+// ProcessFile processes the URLs in input and writes the result to output.
+// input and output may be the same file.
+// The line endings, byte order mark and final newline of input are kept.
+func ProcessFile(input, output string, opts Options) error {
+	source, err := os.ReadFile(input)
 	if err != nil {
-		log.Fatalf("Error reading input file: %v", err)
+		return fmt.Errorf("error reading input file: %w", err)
 	}
-	processedSource := processURLs(source)
+	source, style := eol.Normalize(source)
+	processedSource := processURLs(source, opts)
 	md := goldmark.New(
 		goldmark.WithRenderer(markdown.NewRenderer()),
 	)
 	var buf bytes.Buffer
 	if err := md.Convert(processedSource, &buf); err != nil {
-		log.Fatalf("Error converting markdown: %v", err)
+		return fmt.Errorf("error converting markdown: %w", err)
 	}
-	output := buf.String()
-	if err := os.WriteFile(outputFilename, []byte(output), 0o644); err != nil {
-		log.Fatalf("Error writing output file: %v", err)
+	if err := os.WriteFile(output, style.Apply(buf.Bytes()), 0o644); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
 	}
+	return nil
 }
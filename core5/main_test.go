@@ -6,10 +6,15 @@ import (
 	"testing"
 )
 
+var testURLMap = map[string]string{
+	"https://example.com": "sample website",
+}
+
 func TestProcessURLs(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
+		relaxed  bool
 		expected string
 	}{
 		{
@@ -19,13 +24,18 @@ func TestProcessURLs(t *testing.T) {
 		},
 		{
 			name:     "Single URL",
-			input:    "Check out https://example.com for more info",
-			expected: "Check out https://example.com for more info",
+			input:    "Check out https://test.org for more info",
+			expected: "Check out <https://test.org> for more info",
 		},
 		{
 			name:     "Multiple URLs",
 			input:    "Visit https://example.com and http://test.org",
-			expected: "Visit https://example.com and http://test.org",
+			expected: "Visit [sample website](https://example.com) and <http://test.org>",
+		},
+		{
+			name:     "Balanced parentheses and trailing punctuation",
+			input:    "See https://en.wikipedia.org/wiki/Go_(programming_language), and https://test.org/?a=1&amp;.",
+			expected: "See <https://en.wikipedia.org/wiki/Go_(programming_language)>, and <https://test.org/?a=1>&amp;.",
 		},
 		{
 			name:     "Markdown link",
@@ -35,13 +45,64 @@ func TestProcessURLs(t *testing.T) {
 		{
 			name:     "Mixed content",
 			input:    "Check [this](https://example.com) and https://test.org",
-			expected: "Check [this](https://example.com) and https://test.org",
+			expected: "Check [this](https://example.com) and <https://test.org>",
+		},
+		{
+			name:     "URL as link text",
+			input:    "[https://test.org](https://test.org)",
+			expected: "[https://test.org](https://test.org)",
+		},
+		{
+			name:     "Autolink",
+			input:    "Already <https://test.org>",
+			expected: "Already <https://test.org>",
+		},
+		{
+			name:     "Code span",
+			input:    "Run `curl https://test.org` now",
+			expected: "Run `curl https://test.org` now",
+		},
+		{
+			name:     "Code block",
+			input:    "```\nhttps://test.org\n```\n\n    https://test.org\n",
+			expected: "```\nhttps://test.org\n```\n\n    https://test.org\n",
+		},
+		{
+			name:     "HTML",
+			input:    "An <a href=\"https://test.org\">anchor</a>\n\n<div>\nhttps://test.org\n</div>\n",
+			expected: "An <a href=\"https://test.org\">anchor</a>\n\n<div>\nhttps://test.org\n</div>\n",
+		},
+		{
+			name:     "Trailing punctuation",
+			input:    "See https://test.org. Or https://test.org/a, or https://test.org/b!",
+			expected: "See <https://test.org>. Or <https://test.org/a>, or <https://test.org/b>!",
+		},
+		{
+			name:     "Parentheses",
+			input:    "(see https://test.org/wiki/Go_(language))",
+			expected: "(see <https://test.org/wiki/Go_(language)>)",
+		},
+		{
+			name:     "Underscores",
+			input:    "Read https://test.org/a_b_c here",
+			expected: "Read <https://test.org/a_b_c> here",
+		},
+		{
+			name:     "Scheme-less host ignored by default",
+			input:    "Visit www.test.org today",
+			expected: "Visit www.test.org today",
+		},
+		{
+			name:     "Relaxed scheme-less host",
+			input:    "Visit www.test.org today, not README.md or me@test.org",
+			relaxed:  true,
+			expected: "Visit [www.test.org](http://www.test.org) today, not README.md or me@test.org",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := processURLs([]byte(tt.input))
+			result := processURLs([]byte(tt.input), Options{URLMap: testURLMap, Relaxed: tt.relaxed})
 			if string(result) != tt.expected {
 				t.Errorf("processURLs() = %v, want %v", string(result), tt.expected)
 			}
@@ -65,6 +126,10 @@ func TestMain(t *testing.T) {
 	testInput := `# Test Markdown
 This is a [test link](https://example.com).
 Here's a plain URL: https://test.org
+`
+	expected := `# Test Markdown
+This is a [test link](https://example.com).
+Here's a plain URL: <https://test.org>
 `
 	if _, err := inputFile.Write([]byte(testInput)); err != nil {
 		t.Fatalf("Failed to write to temp input file: %v", err)
@@ -77,15 +142,15 @@ Here's a plain URL: https://test.org
 		inputFilename, outputFilename = oldInputFilename, oldOutputFilename
 	}()
 
-	Main()
+	Main(Options{})
 
 	output, err := os.ReadFile(outputFile.Name())
 	if err != nil {
 		t.Fatalf("Failed to read output file: %v", err)
 	}
 
-	if !bytes.Equal(output, []byte(testInput)) {
-		t.Errorf("Main() output = %v, want %v", string(output), testInput)
+	if !bytes.Equal(output, []byte(expected)) {
+		t.Errorf("Main() output = %v, want %v", string(output), expected)
 	}
 }
 
@@ -94,17 +159,20 @@ func TestSourceOutputComparison(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create temp input file: %v", err)
 	}
+	inputFile.Close()
 	defer os.Remove(inputFile.Name())
 
 	outputFile, err := os.CreateTemp("", "output*.md")
 	if err != nil {
 		t.Fatalf("Failed to create temp output file: %v", err)
 	}
+	outputFile.Close()
 	defer os.Remove(outputFile.Name())
 
 	testCases := []struct {
-		name  string
-		input string
+		name     string
+		input    string
+		expected string
 	}{
 		{
 			name: "Mixed content",
@@ -115,6 +183,14 @@ And here's some code:
 ` + "```" + `
 https://code-example.com
 ` + "```" + `
+`,
+			expected: `# Sample Markdown
+This is a [Markdown link](https://example.com).
+Here's a plain URL: <https://test.org>
+And here's some code:
+` + "```" + `
+https://code-example.com
+` + "```" + `
 `,
 		},
 		{
@@ -128,37 +204,37 @@ No URLs or Markdown links here.
 			input: `Check out [this link](https://example1.com) and [that link](https://example2.com).
 `,
 		},
+		{
+			name:  "CRLF line endings",
+			input: "# Title\r\n\r\nSee [this link](https://example1.com).\r\n",
+		},
+		{
+			name:  "Byte order mark without final newline",
+			input: "\ufeff# Title\n\nSee [this link](https://example1.com).",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			if _, err := inputFile.Write([]byte(tc.input)); err != nil {
+			expected := tc.expected
+			if expected == "" {
+				expected = tc.input
+			}
+			if err := os.WriteFile(inputFile.Name(), []byte(tc.input), 0o644); err != nil {
 				t.Fatalf("Failed to write to temp input file: %v", err)
 			}
-			inputFile.Close()
 
-			oldInputFilename, oldOutputFilename := inputFilename, outputFilename
-			inputFilename, outputFilename = inputFile.Name(), outputFile.Name()
-			defer func() {
-				inputFilename, outputFilename = oldInputFilename, oldOutputFilename
-			}()
-
-			Main()
+			if err := ProcessFile(inputFile.Name(), outputFile.Name(), Options{}); err != nil {
+				t.Fatal(err)
+			}
 
 			output, err := os.ReadFile(outputFile.Name())
 			if err != nil {
 				t.Fatalf("Failed to read output file: %v", err)
 			}
 
-			if !bytes.Equal(output, []byte(tc.input)) {
-				t.Errorf("Output doesn't match input.\nInput:\n%s\nOutput:\n%s", tc.input, string(output))
-			}
-
-			if err := inputFile.Truncate(0); err != nil {
-				t.Fatalf("Failed to truncate input file: %v", err)
-			}
-			if _, err := inputFile.Seek(0, 0); err != nil {
-				t.Fatalf("Failed to seek input file: %v", err)
+			if !bytes.Equal(output, []byte(expected)) {
+				t.Errorf("Output doesn't match expected.\nExpected:\n%s\nOutput:\n%s", expected, string(output))
 			}
 		})
 	}
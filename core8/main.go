@@ -3,6 +3,7 @@ package core8
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -11,27 +12,53 @@ import (
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/extrender"
+	"github.com/gkwa/bravewaldo/internal/table"
 )
 
-func Main() {
+func Format(source []byte) ([]byte, error) {
+	return eol.Process(source, "", format)
+}
+
+// format formats source with LF line endings and no byte order mark.
+func format(source []byte) ([]byte, error) {
 	renderer := markdown.NewRenderer()
 	md := goldmark.New(
 		goldmark.WithRenderer(renderer),
-		goldmark.WithExtensions(extension.GFM, meta.Meta),
+		goldmark.WithExtensions(extension.GFM, extension.DefinitionList, extrender.Footnote, meta.Meta),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
 		),
 	)
+	// Lay out GFM tables, footnotes and definition lists as markdown
+	// instead of the extensions' HTML.
+	md.Renderer().AddOptions(table.NewNodeRenderer(), extrender.NewNodeRenderer())
 
-	source, err := os.ReadFile("testdata/input.md")
+	buf := bytes.Buffer{}
+	if err := md.Convert(source, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func FormatFile(w io.Writer, filename string) error {
+	source, err := os.ReadFile(filename)
 	if err != nil {
-		log.Fatalf("Error reading input file: %v", err)
+		return fmt.Errorf("error reading input file: %w", err)
 	}
 
-	buf := bytes.Buffer{}
-	err = md.Convert(source, &buf)
+	output, err := Format(source)
 	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(w, string(output))
+	return err
+}
+
+func Main() {
+	if err := FormatFile(os.Stdout, "testdata/input.md"); err != nil {
 		log.Fatal(err)
 	}
-	fmt.Print(buf.String())
 }
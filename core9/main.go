@@ -3,29 +3,50 @@ package core9
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
 	markdown "github.com/teekennedy/goldmark-markdown"
 	"github.com/yuin/goldmark"
-)
 
-func Main() {
-	filename := "testdata/input.md"
+	"github.com/gkwa/bravewaldo/internal/eol"
+)
 
-	source, err := os.ReadFile(filename)
-	if err != nil {
-		log.Fatalf("Error reading file: %v", err)
-	}
+func Format(source []byte) ([]byte, error) {
+	return eol.Process(source, "", format)
+}
 
+// format formats source with LF line endings and no byte order mark.
+func format(source []byte) ([]byte, error) {
 	renderer := markdown.NewRenderer(markdown.WithHeadingStyle(markdown.HeadingStyleATX))
 	md := goldmark.New(goldmark.WithRenderer(renderer))
 
 	buf := bytes.Buffer{}
-	err = md.Convert(source, &buf)
+	if err := md.Convert(source, &buf); err != nil {
+		return nil, fmt.Errorf("error converting markdown: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func FormatFile(w io.Writer, filename string) error {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	output, err := Format(source)
 	if err != nil {
-		log.Fatalf("Error converting markdown: %v", err)
+		return err
 	}
+	_, err = w.Write(output)
+	return err
+}
 
-	fmt.Println(buf.String())
+func Main() {
+	filename := "testdata/input.md"
+
+	if err := FormatFile(os.Stdout, filename); err != nil {
+		log.Fatal(err)
+	}
 }
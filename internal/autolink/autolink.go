@@ -0,0 +1,76 @@
+// Package autolink finds the bare URLs of markdown text and ends them where
+// GFM's extended autolinks end: trailing sentence punctuation, closing
+// parentheses without an opening partner and a trailing entity reference
+// are not part of the URL, while balanced parentheses, as in
+// https://en.wikipedia.org/wiki/Go_(programming_language), are.
+package autolink
+
+import (
+	"regexp"
+	"strings"
+
+	"mvdan.cc/xurls/v2"
+
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+var (
+	// Strict matches URLs with a scheme.
+	Strict = xurls.Strict()
+	// Relaxed also matches scheme-less hosts such as www.example.com.
+	Relaxed = xurls.Relaxed()
+	// Web matches http and https URLs.
+	Web = matchingScheme(`https?://`)
+)
+
+var entityRegex = regexp.MustCompile(`&[a-zA-Z0-9]+$`)
+
+func matchingScheme(scheme string) *regexp.Regexp {
+	rx, err := xurls.StrictMatchingScheme(scheme)
+	if err != nil {
+		panic(err)
+	}
+	return rx
+}
+
+// Find returns the spans of the URLs that rx matches in text, trimmed as
+// by Trim.
+func Find(rx *regexp.Regexp, text []byte) []mdast.Span {
+	var spans []mdast.Span
+	for _, loc := range rx.FindAllIndex(text, -1) {
+		url := string(text[loc[0]:loc[1]])
+		// An entity reference such as &amp; ends the URL before it; the
+		// matcher stops at its semicolon.
+		if loc[1] < len(text) && text[loc[1]] == ';' {
+			if m := entityRegex.FindStringIndex(url); m != nil {
+				url = url[:m[0]]
+			}
+		}
+		if url = Trim(url); url != "" {
+			spans = append(spans, mdast.Span{Start: loc[0], Stop: loc[0] + len(url)})
+		}
+	}
+	return spans
+}
+
+// Trim drops trailing punctuation that ends the sentence rather than the
+// URL, closing parentheses that have no opening partner in the URL and a
+// trailing entity reference.
+func Trim(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		switch {
+		case strings.IndexByte(".,:;!?*_~'\"", last) >= 0:
+			if m := entityRegex.FindStringIndex(url[:len(url)-1]); last == ';' && m != nil {
+				url = url[:m[0]]
+				continue
+			}
+			url = url[:len(url)-1]
+		case last == ')' && strings.Count(url, ")") > strings.Count(url, "("):
+			url = url[:len(url)-1]
+		default:
+			return url
+		}
+	}
+	return url
+}
@@ -0,0 +1,41 @@
+package autolink
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		rx   *regexp.Regexp
+		text string
+		want []string
+	}{
+		{Web, "https://en.wikipedia.org/wiki/Go_(programming_language).", []string{"https://en.wikipedia.org/wiki/Go_(programming_language)"}},
+		{Web, "(https://example.com/a_(b)_c)", []string{"https://example.com/a_(b)_c"}},
+		{Web, "(see https://example.com)", []string{"https://example.com"}},
+		{Web, "https://example.com/foo)bar", []string{"https://example.com/foo"}},
+		{Web, "https://example.com, https://example.org;", []string{"https://example.com", "https://example.org"}},
+		{Web, "https://example.com?! https://example.com:", []string{"https://example.com", "https://example.com"}},
+		{Web, "**https://example.com/a_b_**", []string{"https://example.com/a_b"}},
+		{Web, "~https://example.com/~x~~", []string{"https://example.com/~x"}},
+		{Web, `"https://example.com/'quoted'"`, []string{"https://example.com/'quoted"}},
+		{Web, "https://example.com/?q=1&amp;", []string{"https://example.com/?q=1"}},
+		{Web, "https://example.com/?q=1&x=2", []string{"https://example.com/?q=1&x=2"}},
+		{Web, "<https://example.com>", []string{"https://example.com"}},
+		{Web, "ftp://example.com www.example.com", nil},
+		{Strict, "ftp://example.com.", []string{"ftp://example.com"}},
+		{Relaxed, "www.example.com/path.", []string{"www.example.com/path"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, span := range Find(tt.rx, []byte(tt.text)) {
+			got = append(got, string(span.Value([]byte(tt.text))))
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Find(%q) mismatch (-want +got):\n%s", tt.text, diff)
+		}
+	}
+}
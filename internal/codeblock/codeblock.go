@@ -0,0 +1,159 @@
+// Package codeblock reads the fenced code blocks of a markdown document
+// together with the attributes of their info strings, as in
+// ```go file=main.go or ```sh exec timeout=5s.
+package codeblock
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// Info is a parsed info string: the language followed by key=value
+// attributes and bare flags, which have an empty value. Values may be
+// double quoted to contain spaces.
+type Info struct {
+	Language string
+	Attrs    map[string]string
+}
+
+// Has reports whether the attribute or flag key is present.
+func (i Info) Has(key string) bool {
+	_, ok := i.Attrs[key]
+	return ok
+}
+
+// ParseInfo parses an info string. A leading {.lang key=value} brace
+// group, as used by pandoc, is accepted too.
+func ParseInfo(info string) Info {
+	info = strings.TrimSpace(info)
+	if strings.HasPrefix(info, "{") && strings.HasSuffix(info, "}") {
+		info = strings.TrimPrefix(strings.TrimSpace(info[1:len(info)-1]), ".")
+	}
+
+	result := Info{Attrs: map[string]string{}}
+	for i, field := range fields(info) {
+		key, value, _ := strings.Cut(field, "=")
+		if i == 0 && !strings.Contains(field, "=") {
+			result.Language = field
+			continue
+		}
+		result.Attrs[key] = strings.Trim(value, `"`)
+	}
+	return result
+}
+
+// fields splits s at spaces outside double quotes.
+func fields(s string) []string {
+	var result []string
+	var b strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			b.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if b.Len() > 0 {
+				result = append(result, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		result = append(result, b.String())
+	}
+	return result
+}
+
+// Block is a fenced code block. Span runs from the opening fence to the
+// end of the closing fence, or of the last line when the block is not
+// closed, and excludes the final newline. Line is the line of the first
+// content line, or of the line after the opening fence for an empty block.
+type Block struct {
+	Node    *ast.FencedCodeBlock
+	Info    Info
+	Content []byte
+	Span    mdast.Span
+	Line    int
+}
+
+// Blocks returns the fenced code blocks of doc in document order.
+func Blocks(doc ast.Node, source []byte) []Block {
+	var blocks []Block
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		node, ok := n.(*ast.FencedCodeBlock)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		block := Block{Node: node, Content: content(node, source)}
+		if node.Info != nil {
+			block.Info = ParseInfo(string(node.Info.Segment.Value(source)))
+		} else {
+			block.Info = ParseInfo("")
+		}
+
+		start := node.Pos()
+		if start < 0 {
+			return ast.WalkContinue, nil
+		}
+		stop := lineEnd(source, start)
+		if lines := node.Lines(); lines.Len() > 0 {
+			stop = lineEnd(source, lines.At(lines.Len()-1).Start)
+			block.Line = mdast.PositionOf(source, lines.At(0).Start).Line
+		} else {
+			block.Line = mdast.PositionOf(source, stop).Line + 1
+		}
+		if next := stop + 1; next < len(source) && isClosingFence(source[next:lineEnd(source, next)], source[start:lineEnd(source, start)]) {
+			stop = lineEnd(source, next)
+		}
+		block.Span = mdast.Span{Start: start, Stop: stop}
+		blocks = append(blocks, block)
+		return ast.WalkSkipChildren, nil
+	})
+	return blocks
+}
+
+// content joins the lines of a block, restoring the indentation goldmark
+// keeps as padding.
+func content(node *ast.FencedCodeBlock, source []byte) []byte {
+	var b bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		b.WriteString(strings.Repeat(" ", line.Padding))
+		b.Write(line.Value(source))
+	}
+	return b.Bytes()
+}
+
+func lineEnd(source []byte, pos int) int {
+	if i := bytes.IndexByte(source[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(source)
+}
+
+// isClosingFence reports whether line, which may start with a container
+// prefix such as "> ", closes the block opened by the fence line open.
+func isClosingFence(line, open []byte) bool {
+	open = bytes.TrimLeft(open, " ")
+	char := open[0]
+	n := 0
+	for n < len(open) && open[n] == char {
+		n++
+	}
+	trimmed := bytes.TrimSpace(line)
+	i := bytes.IndexByte(trimmed, char)
+	if i < 0 || len(bytes.Trim(trimmed[:i], "> ")) > 0 {
+		return false
+	}
+	fence := bytes.TrimRight(trimmed[i:], string(char))
+	return len(fence) == 0 && len(trimmed)-i >= n
+}
@@ -0,0 +1,49 @@
+package codeblock
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+)
+
+func TestParseInfo(t *testing.T) {
+	for info, want := range map[string]Info{
+		"go file=main.go":              {Language: "go", Attrs: map[string]string{"file": "main.go"}},
+		`sh exec dir="a b" timeout=5s`: {Language: "sh", Attrs: map[string]string{"exec": "", "dir": "a b", "timeout": "5s"}},
+		"{.python file=x.py}":          {Language: "python", Attrs: map[string]string{"file": "x.py"}},
+		"file=notes.txt":               {Attrs: map[string]string{"file": "notes.txt"}},
+		"":                             {Attrs: map[string]string{}},
+	} {
+		if diff := cmp.Diff(want, ParseInfo(info)); diff != "" {
+			t.Errorf("ParseInfo(%q) mismatch (-want +got):\n%s", info, diff)
+		}
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	source := []byte("# Doc\n\n```go file=a.go\npackage a\n```\n\n> ~~~~sh\n> echo hi\n> ~~~~\n\n- item\n\n  ```\n    indented\n  ```\n\n```text\nunclosed\n")
+	blocks := Blocks(core.NewGoldmark().Parser().Parse(text.NewReader(source)), source)
+
+	type result struct {
+		Language string
+		Content  string
+		Span     string
+		Line     int
+	}
+	var got []result
+	for _, b := range blocks {
+		got = append(got, result{b.Info.Language, string(b.Content), string(b.Span.Value(source)), b.Line})
+	}
+	want := []result{
+		{"go", "package a\n", "```go file=a.go\npackage a\n```", 4},
+		{"sh", "echo hi\n", "~~~~sh\n> echo hi\n> ~~~~", 8},
+		{"", "  indented\n", "```\n    indented\n  ```", 14},
+		{"text", "unclosed\n", "```text\nunclosed", 18},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Blocks mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,184 @@
+// Package eol keeps the line ending style of a markdown document, its
+// UTF-8 byte order mark and whether it ends with a newline, so that
+// output generated from it can be written back the same way.
+package eol
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	LF   = "\n"
+	CRLF = "\r\n"
+)
+
+// BOM is the UTF-8 byte order mark.
+var BOM = []byte("\xef\xbb\xbf")
+
+// Style is how a document ends its lines.
+type Style struct {
+	// LineEnding is LF or CRLF.
+	LineEnding string
+	// BOM is set when the document starts with a UTF-8 byte order mark.
+	BOM bool
+	// FinalNewline is set when the last line ends with a line ending.
+	FinalNewline bool
+}
+
+// Detect returns the style of source. Its line ending is the one most of
+// its lines end with, LF on a tie or when there is only one line.
+func Detect(source []byte) Style {
+	s := Style{LineEnding: LF}
+	if bytes.HasPrefix(source, BOM) {
+		s.BOM = true
+		source = source[len(BOM):]
+	}
+	crlf := bytes.Count(source, []byte(CRLF))
+	if crlf > bytes.Count(source, []byte(LF))-crlf {
+		s.LineEnding = CRLF
+	}
+	s.FinalNewline = bytes.HasSuffix(source, []byte(LF))
+	return s
+}
+
+// Normalize returns source without its byte order mark and with LF line
+// endings, the form the parsers work on, together with its style.
+func Normalize(source []byte) ([]byte, Style) {
+	s := Detect(source)
+	source = bytes.TrimPrefix(source, BOM)
+	return bytes.ReplaceAll(source, []byte(CRLF), []byte(LF)), s
+}
+
+// Apply returns output, written with LF or CRLF line endings, in style s:
+// with its line endings, byte order mark and final newline. A document
+// that had no final newline gets none, however many output ends with, and
+// one that had gets one unless output is empty or already ends with one.
+func (s Style) Apply(output []byte) []byte {
+	output = bytes.ReplaceAll(bytes.TrimPrefix(output, BOM), []byte(CRLF), []byte(LF))
+	if !s.FinalNewline {
+		output = bytes.TrimRight(output, LF)
+	} else if len(output) > 0 && !bytes.HasSuffix(output, []byte(LF)) {
+		output = append(output, '\n')
+	}
+	if s.LineEnding == CRLF {
+		output = bytes.ReplaceAll(output, []byte(LF), []byte(CRLF))
+	}
+	if s.BOM {
+		output = append(append([]byte{}, BOM...), output...)
+	}
+	return output
+}
+
+// WithLineEnding returns s with lineEnding instead of its own line ending,
+// or s itself when lineEnding is empty.
+func (s Style) WithLineEnding(lineEnding string) Style {
+	if lineEnding != "" {
+		s.LineEnding = lineEnding
+	}
+	return s
+}
+
+// Process runs fn over the normalized source and returns its result in
+// the style of source, with lineEnding instead of the line ending of
+// source when it is set.
+func Process(source []byte, lineEnding string, fn func([]byte) ([]byte, error)) ([]byte, error) {
+	normalized, style := Normalize(source)
+	output, err := fn(normalized)
+	if err != nil {
+		return nil, err
+	}
+	return style.WithLineEnding(lineEnding).Apply(output), nil
+}
+
+// Match returns output, generated from source or to replace it, in the
+// style of source, with lineEnding instead of its line ending when it is
+// set.
+func Match(source, output []byte, lineEnding string) []byte {
+	return Detect(source).WithLineEnding(lineEnding).Apply(output)
+}
+
+// ParseLineEnding returns the line ending named by name: "lf", "crlf", or
+// "" for "auto" and the empty string, which keep each document's own.
+func ParseLineEnding(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return "", nil
+	case "lf":
+		return LF, nil
+	case "crlf":
+		return CRLF, nil
+	}
+	return "", fmt.Errorf("unknown line ending %q, want auto, lf or crlf", name)
+}
+
+// Writer converts the LF and CRLF line endings written to it to a single
+// line ending. Other bytes, including carriage returns not followed by a
+// line feed, pass through unchanged.
+type Writer struct {
+	w      io.Writer
+	ending string
+	cr     bool
+}
+
+// NewWriter returns a Writer that writes to w with lineEnding, or passes
+// everything through as is when lineEnding is empty.
+func NewWriter(w io.Writer, lineEnding string) *Writer {
+	return &Writer{w: w, ending: lineEnding}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.ending == "" {
+		return w.w.Write(p)
+	}
+	out := make([]byte, 0, len(p)+len(p)/16)
+	for _, b := range p {
+		if w.cr {
+			w.cr = false
+			if b == '\n' {
+				out = append(out, w.ending...)
+				continue
+			}
+			out = append(out, '\r')
+		}
+		switch b {
+		case '\r':
+			// Held back until the next byte shows whether it ends a line.
+			w.cr = true
+		case '\n':
+			out = append(out, w.ending...)
+		default:
+			out = append(out, b)
+		}
+	}
+	if _, err := w.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes a carriage return still held back. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if !w.cr {
+		return nil
+	}
+	w.cr = false
+	_, err := w.w.Write([]byte{'\r'})
+	return err
+}
+
+// Convert returns source with its line endings changed to lineEnding, or
+// unchanged when lineEnding is empty.
+func Convert(source []byte, lineEnding string) []byte {
+	if lineEnding == "" {
+		return source
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, lineEnding)
+	_, _ = w.Write(source)
+	_ = w.Close()
+	return buf.Bytes()
+}
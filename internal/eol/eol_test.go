@@ -0,0 +1,89 @@
+package eol
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		source string
+		want   Style
+	}{
+		{"", Style{LineEnding: LF}},
+		{"one line", Style{LineEnding: LF}},
+		{"a\nb\n", Style{LineEnding: LF, FinalNewline: true}},
+		{"a\r\nb\r\n", Style{LineEnding: CRLF, FinalNewline: true}},
+		{"a\r\nb\r\nc\n", Style{LineEnding: CRLF, FinalNewline: true}},
+		{"a\r\nb\nc", Style{LineEnding: LF}},
+		{"\ufeffa\r\nb", Style{LineEnding: CRLF, BOM: true}},
+	}
+	for _, tt := range tests {
+		if got := Detect([]byte(tt.source)); got != tt.want {
+			t.Errorf("Detect(%q) = %+v, want %+v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestProcessRoundTrip(t *testing.T) {
+	for _, source := range []string{
+		"# Title\n\nText\n",
+		"# Title\r\n\r\nText\r\n",
+		"# Title\r\n\r\nText",
+		"\ufeff# Title\r\n\r\nText\r\n",
+		"\ufeff# Title\n",
+	} {
+		got, err := Process([]byte(source), "", func(normalized []byte) ([]byte, error) {
+			if bytes.Contains(normalized, []byte("\r")) || bytes.HasPrefix(normalized, BOM) {
+				t.Errorf("not normalized: %q", normalized)
+			}
+			// Formatters always end with a newline.
+			return append(bytes.TrimRight(normalized, "\n"), '\n'), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(source, string(got)); diff != "" {
+			t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestParseLineEnding(t *testing.T) {
+	for name, want := range map[string]string{"": "", "auto": "", "LF": LF, "crlf": CRLF} {
+		got, err := ParseLineEnding(name)
+		if err != nil || got != want {
+			t.Errorf("ParseLineEnding(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseLineEnding("cr"); err == nil {
+		t.Error("ParseLineEnding(\"cr\") succeeded")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	// The CRLF is split across writes.
+	writes := []string{"a\r", "\nb\nc\r", "d\r"}
+	for ending, want := range map[string]string{
+		"":   "a\r\nb\nc\rd\r",
+		LF:   "a\nb\nc\rd\r",
+		CRLF: "a\r\nb\r\nc\rd\r",
+	} {
+		var buf strings.Builder
+		w := NewWriter(&buf, ending)
+		for _, s := range writes {
+			if _, err := w.Write([]byte(s)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("ending %q: got %q, want %q", ending, got, want)
+		}
+	}
+}
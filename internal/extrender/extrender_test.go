@@ -0,0 +1,144 @@
+package extrender_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/gkwa/bravewaldo/core2"
+	"github.com/gkwa/bravewaldo/core8"
+	"github.com/gkwa/bravewaldo/internal/extrender"
+)
+
+var documents = map[string]string{
+	"footnotes": `Text with a note[^1] and another[^long], twice[^1].
+
+[^1]: The first note.
+
+Between the notes.
+
+[^long]: A long note
+    with two lines.
+
+    And a paragraph.
+[^unused]: Nobody refers to this.
+
+> Quote[^q]
+>
+> [^q]: Quoted note.
+
+- Item[^item]
+
+  [^item]: In a list.
+`,
+	"definition lists": `Intro paragraph.
+
+Term
+: Definition
+  continued
+: Second *definition*
+
+Other term
+Second term
+
+: Loose definition.
+
+  Second paragraph.
+
+Last term
+: - a list
+  - in a definition
+
+After the list.
+`,
+	"mixed": `# Glossary
+
+API
+: Application programming interface[^api].
+
+[^api]: See [the spec](https://example.com/spec "Spec").
+`,
+}
+
+// shape describes the AST of source one node per line, with the details
+// that matter for the document's meaning but not its source positions.
+func shape(t *testing.T, source string) string {
+	t.Helper()
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM, extension.DefinitionList, extrender.Footnote))
+	src := []byte(source)
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	var b strings.Builder
+	depth := 0
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			depth--
+			return ast.WalkContinue, nil
+		}
+		fmt.Fprintf(&b, "%s%s", strings.Repeat("  ", depth), n.Kind())
+		switch n := n.(type) {
+		case *ast.Text:
+			fmt.Fprintf(&b, " %q soft=%t", n.Segment.Value(src), n.SoftLineBreak())
+		case *ast.Heading:
+			fmt.Fprintf(&b, " level=%d", n.Level)
+		case *ast.Link:
+			fmt.Fprintf(&b, " %q %q", n.Destination, n.Title)
+		case *extast.Footnote:
+			fmt.Fprintf(&b, " ref=%q", n.Ref)
+		case *extast.FootnoteLink:
+			ref, _ := n.AttributeString("ref")
+			fmt.Fprintf(&b, " ref=%q", ref)
+		case *extast.DefinitionDescription:
+			fmt.Fprintf(&b, " tight=%t", n.IsTight)
+		}
+		b.WriteString("\n")
+		depth++
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+func TestRoundTrip(t *testing.T) {
+	formatters := map[string]func([]byte) ([]byte, error){
+		"core2": core2.Format,
+		"core8": core8.Format,
+	}
+	for name, source := range documents {
+		for formatter, format := range formatters {
+			t.Run(name+"/"+formatter, func(t *testing.T) {
+				formatted, err := format([]byte(source))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(shape(t, source), shape(t, string(formatted))); diff != "" {
+					t.Errorf("formatting changed the AST (-want +got):\n%s\nformatted:\n%s", diff, formatted)
+				}
+				again, err := format(formatted)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(string(formatted), string(again)); diff != "" {
+					t.Errorf("formatting is not stable (-first +second):\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestFootnotesStayInPlace(t *testing.T) {
+	source := documents["footnotes"]
+	formatted, err := core2.Format([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(source, string(formatted)); diff != "" {
+		t.Errorf("Format() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,121 @@
+// Package extrender renders the nodes of goldmark's footnote and
+// definition list extensions back to markdown for the goldmark-markdown
+// renderer, which has no renderers of its own for them and otherwise falls
+// back to the extensions' HTML.
+package extrender
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// refAttr is the attribute that carries the label of a footnote
+// reference, which the footnote extension replaces by a number.
+const refAttr = "ref"
+
+var placementsKey = parser.NewContextKey()
+
+// placement is where a footnote definition was written.
+type placement struct {
+	footnote *extast.Footnote
+	parent   ast.Node
+	prev     ast.Node
+}
+
+type footnoteExtension struct{}
+
+// Footnote parses footnotes like the footnote extension but keeps the
+// document as written: definitions stay where they are, in their order
+// and even when nothing refers to them, references keep their labels and
+// no backlinks are added. Use it instead of extension.Footnote when
+// rendering markdown.
+var Footnote goldmark.Extender = footnoteExtension{}
+
+func (footnoteExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(footnoteBlockParser{extension.NewFootnoteBlockParser()}, 999),
+		),
+		parser.WithInlineParsers(
+			util.Prioritized(extension.NewFootnoteParser(), 101),
+		),
+		parser.WithASTTransformers(
+			util.Prioritized(footnoteTransformer{}, 999),
+		),
+	)
+}
+
+// footnoteBlockParser records where each definition was before the
+// extension's parser moves it into the footnote list, which references
+// are resolved against while inlines are parsed.
+type footnoteBlockParser struct {
+	parser.BlockParser
+}
+
+func (b footnoteBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	p := placement{footnote: node.(*extast.Footnote), parent: node.Parent(), prev: node.PreviousSibling()}
+	b.BlockParser.Close(node, reader, pc)
+	placements, _ := pc.Get(placementsKey).([]placement)
+	pc.Set(placementsKey, append(placements, p))
+}
+
+// footnoteTransformer labels references and puts the definitions back.
+type footnoteTransformer struct{}
+
+func (footnoteTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	placements, _ := pc.Get(placementsKey).([]placement)
+	pc.Set(placementsKey, nil)
+	if len(placements) == 0 {
+		return
+	}
+
+	refs := map[int][]byte{}
+	for _, p := range placements {
+		if p.footnote.Index >= 0 {
+			refs[p.footnote.Index] = p.footnote.Ref
+		}
+	}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if link, ok := n.(*extast.FootnoteLink); ok && entering {
+			link.SetAttributeString(refAttr, refs[link.Index])
+		}
+		return ast.WalkContinue, nil
+	})
+
+	// Consecutive definitions were all removed after the same sibling, so
+	// each is put back after the one restored before it.
+	list := placements[0].footnote.Parent()
+	restored := map[ast.Node]ast.Node{}
+	for _, p := range placements {
+		fn := p.footnote
+		fn.Parent().RemoveChild(fn.Parent(), fn)
+		key := p.prev
+		if key == nil {
+			key = p.parent
+		}
+		prev, ok := restored[key]
+		if !ok {
+			prev = p.prev
+		}
+		restored[key] = fn
+		switch {
+		case prev == list:
+			// The list took the place of the first definition.
+			p.parent.InsertBefore(p.parent, list, fn)
+		case prev != nil && prev.Parent() == p.parent:
+			p.parent.InsertAfter(p.parent, prev, fn)
+		case prev == nil && p.parent.FirstChild() != nil:
+			p.parent.InsertBefore(p.parent, p.parent.FirstChild(), fn)
+		default:
+			p.parent.AppendChild(p.parent, fn)
+		}
+	}
+	if list != nil && list.Parent() != nil {
+		list.Parent().RemoveChild(list.Parent(), list)
+	}
+}
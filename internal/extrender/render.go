@@ -0,0 +1,158 @@
+package extrender
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// markdownWriter is the part of the goldmark-markdown writer that lays
+// out container blocks: prefixes pushed for a range of lines are written
+// before each of them when a line is flushed.
+type markdownWriter interface {
+	util.BufWriter
+	PushPrefix(prefix []byte, lineRanges ...int)
+	PopPrefix()
+	EndLine()
+	FlushLine()
+}
+
+// NodeRenderer renders footnotes and definition lists as markdown.
+type NodeRenderer struct{}
+
+// NewNodeRenderer returns the renderer as a renderer option, to be added
+// after the extensions have registered their HTML renderers.
+func NewNodeRenderer() renderer.Option {
+	return renderer.WithNodeRenderers(util.Prioritized(NodeRenderer{}, 100))
+}
+
+func (r NodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(extast.KindFootnoteLink, r.renderFootnoteLink)
+	reg.Register(extast.KindFootnoteBacklink, r.renderFootnoteBacklink)
+	reg.Register(extast.KindFootnote, r.renderFootnote)
+	reg.Register(extast.KindFootnoteList, r.renderFootnoteList)
+	reg.Register(extast.KindDefinitionList, r.renderDefinitionList)
+	reg.Register(extast.KindDefinitionTerm, r.renderDefinitionTerm)
+	reg.Register(extast.KindDefinitionDescription, r.renderDefinitionDescription)
+}
+
+func writer(w util.BufWriter) (markdownWriter, error) {
+	mw, ok := w.(markdownWriter)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a goldmark-markdown writer", w)
+	}
+	return mw, nil
+}
+
+// block separates a block from the one before it with a blank line when
+// blank is set, and ends its last line when leaving it. goldmark-markdown
+// does this for its own blocks but not for registered ones.
+func block(w markdownWriter, entering, blank bool) {
+	if !entering {
+		w.FlushLine()
+		return
+	}
+	if blank {
+		w.EndLine()
+	}
+}
+
+func (r NodeRenderer) renderFootnoteLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*extast.FootnoteLink)
+	ref := strconv.Itoa(n.Index)
+	if value, ok := n.AttributeString(refAttr); ok {
+		if b, ok := value.([]byte); ok && len(b) > 0 {
+			ref = string(b)
+		}
+	}
+	_, err := w.WriteString("[^" + ref + "]")
+	return ast.WalkContinue, err
+}
+
+// renderFootnoteBacklink writes nothing: backlinks are added by the
+// footnote extension's transformer and have no markdown syntax.
+func (r NodeRenderer) renderFootnoteBacklink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	return ast.WalkSkipChildren, nil
+}
+
+func (r NodeRenderer) renderFootnote(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	mw, err := writer(w)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	n := node.(*extast.Footnote)
+	prev := n.PreviousSibling()
+	block(mw, entering, prev != nil && (n.HasBlankPreviousLines() || ast.IsParagraph(prev)))
+	label := "[^" + string(n.Ref) + "]:"
+	switch {
+	case !n.HasChildren():
+		if entering {
+			_, err = mw.WriteString(label)
+		}
+	case entering:
+		// Content continues on the label's line and is indented by four
+		// spaces on the lines after it.
+		mw.PushPrefix([]byte(label+" "), 0, 0)
+		mw.PushPrefix([]byte("    "), 1)
+	default:
+		mw.PopPrefix()
+		mw.PopPrefix()
+	}
+	return ast.WalkContinue, err
+}
+
+func (r NodeRenderer) renderFootnoteList(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	mw, err := writer(w)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	block(mw, entering, node.PreviousSibling() != nil)
+	return ast.WalkContinue, nil
+}
+
+func (r NodeRenderer) renderDefinitionList(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	mw, err := writer(w)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	// A term right after a paragraph would continue it.
+	block(mw, entering, node.PreviousSibling() != nil)
+	return ast.WalkContinue, nil
+}
+
+func (r NodeRenderer) renderDefinitionTerm(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	mw, err := writer(w)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	// A term right after a description would continue its text.
+	prev := node.PreviousSibling()
+	block(mw, entering, prev != nil && prev.Kind() == extast.KindDefinitionDescription)
+	return ast.WalkContinue, nil
+}
+
+func (r NodeRenderer) renderDefinitionDescription(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	mw, err := writer(w)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	n := node.(*extast.DefinitionDescription)
+	// Loose descriptions, with paragraphs instead of text blocks, are
+	// separated from their term by a blank line.
+	block(mw, entering, !n.IsTight)
+	if entering {
+		mw.PushPrefix([]byte(": "), 0, 0)
+		mw.PushPrefix([]byte("  "), 1)
+	} else {
+		mw.PopPrefix()
+		mw.PopPrefix()
+	}
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,88 @@
+package files
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var markdownExtensions = []string{".md", ".markdown"}
+
+// IsMarkdown reports whether path has a markdown file extension.
+func IsMarkdown(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range markdownExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Markdown expands paths into the markdown files they name. Files are
+// returned as given, directories are walked recursively for markdown files
+// and hidden directories are skipped. The result is sorted and deduplicated.
+func Markdown(paths []string) ([]string, error) {
+	seen := map[string]bool{}
+	var result []string
+
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			result = append(result, path)
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			add(path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if IsMarkdown(p) {
+				add(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", path, err)
+		}
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// LocalTarget resolves a relative link destination against the markdown
+// file at path. Destinations with a scheme, absolute paths and
+// same-document fragments report false.
+func LocalTarget(path, destination string) (string, bool) {
+	if destination == "" || strings.HasPrefix(destination, "#") || strings.HasPrefix(destination, "/") {
+		return "", false
+	}
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+	return filepath.Join(filepath.Dir(path), filepath.FromSlash(u.Path)), true
+}
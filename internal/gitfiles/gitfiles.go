@@ -0,0 +1,187 @@
+// Package gitfiles selects files by their state in a git repository, using
+// go-git rather than the git command.
+package gitfiles
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+func open(dir string) (*git.Repository, string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := git.PlainOpenWithOptions(abs, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open git repository at %s: %w", dir, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, "", err
+	}
+	return repo, wt.Filesystem.Root(), nil
+}
+
+// Changed returns the absolute paths of the existing files that differ
+// between rev and the working tree of the repository containing dir: files
+// changed by commits since rev plus staged, modified and untracked files.
+func Changed(dir, rev string) (map[string]bool, error) {
+	repo, root, err := open(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	fromTree, err := commitTree(repo, *hash)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	headTree, err := commitTree(repo, head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(fromTree, headTree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s with HEAD: %w", rev, err)
+	}
+	result := map[string]bool{}
+	for _, change := range changes {
+		if change.To.Name != "" {
+			result[filepath.Join(root, filepath.FromSlash(change.To.Name))] = true
+		}
+	}
+
+	status, err := worktreeStatus(repo)
+	if err != nil {
+		return nil, err
+	}
+	for path, s := range status {
+		name := filepath.Join(root, filepath.FromSlash(path))
+		switch {
+		case s.Worktree == git.Deleted || (s.Staging == git.Deleted && s.Worktree == git.Unmodified):
+			delete(result, name)
+		case s.Staging != git.Unmodified || s.Worktree != git.Unmodified:
+			result[name] = true
+		}
+	}
+	return result, nil
+}
+
+// Staged returns the absolute paths of the files added, modified, renamed
+// or copied in the index of the repository containing dir.
+func Staged(dir string) (map[string]bool, error) {
+	repo, root, err := open(dir)
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktreeStatus(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]bool{}
+	for path, s := range status {
+		switch s.Staging {
+		case git.Added, git.Modified, git.Renamed, git.Copied:
+			result[filepath.Join(root, filepath.FromSlash(path))] = true
+		}
+	}
+	return result, nil
+}
+
+// ReadStaged returns the content of the file at path as it is staged in
+// the index of its repository, which may differ from the working tree.
+func ReadStaged(path string) ([]byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	repo, root, err := open(filepath.Dir(abs))
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git index: %w", err)
+	}
+	entry, err := index.Entry(filepath.ToSlash(rel))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not staged: %w", path, err)
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged %s: %w", path, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged %s: %w", path, err)
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// HooksDir returns the directory git runs hooks from for the repository
+// containing dir, honouring core.hooksPath.
+func HooksDir(dir string) (string, error) {
+	repo, root, err := open(dir)
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", fmt.Errorf("failed to read git config: %w", err)
+	}
+	if hooksPath := cfg.Raw.Section("core").Option("hooksPath"); hooksPath != "" {
+		if filepath.IsAbs(hooksPath) {
+			return hooksPath, nil
+		}
+		return filepath.Join(root, hooksPath), nil
+	}
+
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", fmt.Errorf("repository at %s has no git directory", root)
+	}
+	return filepath.Join(storage.Filesystem().Root(), "hooks"), nil
+}
+
+func commitTree(repo *git.Repository, hash plumbing.Hash) (*object.Tree, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	return commit.Tree()
+}
+
+func worktreeStatus(repo *git.Repository) (git.Status, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git status: %w", err)
+	}
+	return status, nil
+}
@@ -0,0 +1,120 @@
+package gitfiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func write(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commit(t *testing.T, wt *git.Worktree, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if _, err := wt.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := wt.Commit("commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+var cmpSorted = cmpopts.SortSlices(func(a, b string) bool { return a < b })
+
+func names(dir string, paths map[string]bool) []string {
+	result := []string{}
+	for path := range paths {
+		rel, _ := filepath.Rel(dir, path)
+		result = append(result, rel)
+	}
+	return result
+}
+
+func TestChangedAndStaged(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a.md", "b.md", "c.md", "d.md"} {
+		write(t, dir, name, "# "+name+"\n")
+	}
+	commit(t, wt, "a.md", "b.md", "c.md", "d.md")
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(t, dir, "a.md", "# a changed and committed\n")
+	commit(t, wt, "a.md")
+	write(t, dir, "b.md", "# b staged\n")
+	if _, err := wt.Add("b.md"); err != nil {
+		t.Fatal(err)
+	}
+	write(t, dir, "b.md", "# b staged, then edited\n")
+	write(t, dir, "c.md", "# c modified\n")
+	write(t, dir, "new.md", "# untracked\n")
+	if err := os.Remove(filepath.Join(dir, "d.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := Changed(dir, head.Hash().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := names(dir, changed)
+	want := []string{"a.md", "b.md", "c.md", "new.md"}
+	if diff := cmp.Diff(want, got, cmpSorted); diff != "" {
+		t.Errorf("Changed mismatch (-want +got):\n%s", diff)
+	}
+
+	staged, err := Staged(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"b.md"}, names(dir, staged)); diff != "" {
+		t.Errorf("Staged mismatch (-want +got):\n%s", diff)
+	}
+
+	content, err := ReadStaged(filepath.Join(dir, "b.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "# b staged\n" {
+		t.Errorf("ReadStaged(b.md) = %q, want the staged content", content)
+	}
+	if _, err := ReadStaged(filepath.Join(dir, "new.md")); err == nil {
+		t.Error("ReadStaged(new.md) succeeded for an untracked file")
+	}
+
+	hooks, err := HooksDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".git", "hooks"); hooks != want {
+		t.Errorf("HooksDir = %s, want %s", hooks, want)
+	}
+}
@@ -0,0 +1,309 @@
+// Package graph builds the directed graph of markdown documents formed by
+// their relative links and [[wikilinks]], and renders it as Graphviz DOT,
+// a Mermaid flowchart or GraphML.
+package graph
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+type NodeKind string
+
+const (
+	KindDocument NodeKind = "document"
+	// KindExternal nodes stand for all the URLs of one domain.
+	KindExternal NodeKind = "external"
+	// KindMissing nodes are link targets that do not exist.
+	KindMissing NodeKind = "missing"
+)
+
+// Node IDs are slash separated paths relative to the graph root for
+// documents and missing targets, and "external:" followed by the host name
+// for external domains.
+type Node struct {
+	ID    string
+	Label string
+	Kind  NodeKind
+}
+
+type Edge struct {
+	From   string
+	To     string
+	Broken bool
+}
+
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+type Options struct {
+	// External adds a node for each linked external domain.
+	External bool
+	// Broken adds edges to link targets that do not exist.
+	Broken bool
+	// Start limits the graph to documents reachable from this document,
+	// given relative to the graph root.
+	Start string
+	// Depth limits how many links from Start are followed. Zero means no
+	// limit.
+	Depth int
+	// ExcludeOrphans drops nodes without edges.
+	ExcludeOrphans bool
+}
+
+var wikilinkRegex = regexp.MustCompile(`\[\[([^\[\]|#]+)(?:#[^\[\]|]*)?(?:\|[^\[\]]*)?\]\]`)
+
+// Build reads the markdown files under root into a graph.
+func Build(root string, opts Options) (*Graph, error) {
+	paths, err := files.Markdown([]string{root})
+	if err != nil {
+		return nil, err
+	}
+
+	docs := map[string]bool{}
+	// byName finds wikilink targets by file name, see wikiName.
+	byName := map[string][]string{}
+	for _, path := range paths {
+		id, err := relID(root, path)
+		if err != nil {
+			return nil, err
+		}
+		docs[id] = true
+		name := wikiName(filepath.Base(path))
+		byName[name] = append(byName[name], id)
+	}
+
+	g := &builder{nodes: map[string]Node{}, edges: map[Edge]bool{}}
+	for _, path := range paths {
+		from, _ := relID(root, path)
+		g.node(Node{ID: from, Label: from, Kind: KindDocument})
+
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+		for _, destination := range destinations(doc, source) {
+			if u, err := url.Parse(destination); err == nil && u.Scheme != "" {
+				if opts.External && u.Host != "" {
+					host := strings.ToLower(u.Hostname())
+					g.node(Node{ID: "external:" + host, Label: host, Kind: KindExternal})
+					g.edge(Edge{From: from, To: "external:" + host})
+				}
+				continue
+			}
+
+			target, ok := files.LocalTarget(path, destination)
+			if !ok {
+				continue
+			}
+			to, err := relID(root, target)
+			if err != nil || to == from {
+				continue
+			}
+			switch {
+			case docs[to]:
+				g.edge(Edge{From: from, To: to})
+			case exists(target):
+				// Images and other files are not documents.
+			case opts.Broken:
+				g.node(Node{ID: to, Label: to, Kind: KindMissing})
+				g.edge(Edge{From: from, To: to, Broken: true})
+			}
+		}
+
+		for _, name := range wikilinks(doc, source) {
+			targets := byName[wikiName(name)]
+			switch {
+			case len(targets) > 0:
+				if targets[0] != from {
+					g.edge(Edge{From: from, To: targets[0]})
+				}
+			case opts.Broken:
+				id := "[[" + name + "]]"
+				g.node(Node{ID: id, Label: id, Kind: KindMissing})
+				g.edge(Edge{From: from, To: id, Broken: true})
+			}
+		}
+	}
+
+	result := g.graph()
+	if opts.Start != "" {
+		start := filepath.ToSlash(filepath.Clean(opts.Start))
+		if !docs[start] {
+			return nil, fmt.Errorf("%s is not a markdown document under %s", opts.Start, root)
+		}
+		result = result.reachable(start, opts.Depth)
+	}
+	if opts.ExcludeOrphans {
+		result = result.withoutOrphans()
+	}
+	return result, nil
+}
+
+func relID(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// wikiName is the lookup key of a wikilink target: its last path element
+// in lower case, without a markdown extension, with spaces matching the
+// hyphens file names usually use instead.
+func wikiName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = name[strings.LastIndex(name, "/")+1:]
+	if files.IsMarkdown(name) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return strings.ReplaceAll(name, " ", "-")
+}
+
+// destinations returns the destinations of the links and images of doc,
+// including reference links.
+func destinations(doc ast.Node, source []byte) []string {
+	var result []string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Link:
+			result = append(result, string(node.Destination))
+		case *ast.Image:
+			result = append(result, string(node.Destination))
+		case *ast.AutoLink:
+			result = append(result, string(node.URL(source)))
+		}
+		return ast.WalkContinue, nil
+	})
+	return result
+}
+
+// wikilinks returns the targets of the [[target#heading|label]] wikilinks
+// in the text of doc.
+func wikilinks(doc ast.Node, source []byte) []string {
+	var result []string
+	for _, run := range mdast.TextRuns(doc) {
+		for _, m := range wikilinkRegex.FindAllSubmatch(run.Value(source), -1) {
+			result = append(result, strings.TrimSpace(string(m[1])))
+		}
+	}
+	return result
+}
+
+type builder struct {
+	nodes map[string]Node
+	edges map[Edge]bool
+}
+
+// node adds n unless a node with its ID exists.
+func (b *builder) node(n Node) {
+	if _, ok := b.nodes[n.ID]; !ok {
+		b.nodes[n.ID] = n
+	}
+}
+
+func (b *builder) edge(e Edge) {
+	b.edges[e] = true
+}
+
+func (b *builder) graph() *Graph {
+	g := &Graph{}
+	for _, n := range b.nodes {
+		g.Nodes = append(g.Nodes, n)
+	}
+	for e := range b.edges {
+		g.Edges = append(g.Edges, e)
+	}
+	g.sort()
+	return g
+}
+
+func (g *Graph) sort() {
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+}
+
+// reachable returns the subgraph of the nodes reachable from start over at
+// most depth edges, or any number when depth is zero.
+func (g *Graph) reachable(start string, depth int) *Graph {
+	out := map[string][]string{}
+	for _, e := range g.Edges {
+		out[e.From] = append(out[e.From], e.To)
+	}
+
+	distance := map[string]int{start: 0}
+	queue := []string{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if depth > 0 && distance[id] >= depth {
+			continue
+		}
+		for _, to := range out[id] {
+			if _, seen := distance[to]; !seen {
+				distance[to] = distance[id] + 1
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	return g.filter(func(id string) bool {
+		_, ok := distance[id]
+		return ok
+	})
+}
+
+func (g *Graph) withoutOrphans() *Graph {
+	linked := map[string]bool{}
+	for _, e := range g.Edges {
+		linked[e.From] = true
+		linked[e.To] = true
+	}
+	return g.filter(func(id string) bool { return linked[id] })
+}
+
+// filter keeps the nodes for which keep reports true and the edges
+// between them.
+func (g *Graph) filter(keep func(id string) bool) *Graph {
+	result := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	for _, n := range g.Nodes {
+		if keep(n.ID) {
+			result.Nodes = append(result.Nodes, n)
+		}
+	}
+	for _, e := range g.Edges {
+		if keep(e.From) && keep(e.To) {
+			result.Edges = append(result.Edges, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gkwa/bravewaldo/internal/testutil"
+)
+
+func writeTree(t *testing.T, tree map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, tree)
+	return dir
+}
+
+var tree = map[string]string{
+	"index.md":         "# Index\n\n[Guide](docs/guide.md#install), [[Setup Notes|setup]] and <https://Example.com/a>.\n",
+	"docs/guide.md":    "# Guide\n\n![diagram](diagram.png) [Deep](deep.md) [gone](missing.md) [[Nowhere]]\n",
+	"docs/deep.md":     "# Deep\n\n[Back](../index.md) [Go](https://go.dev)\n",
+	"docs/diagram.png": "",
+	"setup-notes.md":   "# Setup\n\n`[[index]]` is code.\n",
+	"orphan.md":        "# Orphan\n",
+}
+
+func TestBuild(t *testing.T) {
+	dir := writeTree(t, tree)
+
+	tests := []struct {
+		name      string
+		opts      Options
+		wantNodes []string
+		wantEdges []Edge
+	}{
+		{
+			name:      "documents",
+			wantNodes: []string{"docs/deep.md", "docs/guide.md", "index.md", "orphan.md", "setup-notes.md"},
+			wantEdges: []Edge{
+				{From: "docs/deep.md", To: "index.md"},
+				{From: "docs/guide.md", To: "docs/deep.md"},
+				{From: "index.md", To: "docs/guide.md"},
+				{From: "index.md", To: "setup-notes.md"},
+			},
+		},
+		{
+			name: "external and broken",
+			opts: Options{External: true, Broken: true, ExcludeOrphans: true},
+			wantNodes: []string{
+				"[[Nowhere]]", "docs/deep.md", "docs/guide.md", "docs/missing.md",
+				"external:example.com", "external:go.dev", "index.md", "setup-notes.md",
+			},
+			wantEdges: []Edge{
+				{From: "docs/deep.md", To: "external:go.dev"},
+				{From: "docs/deep.md", To: "index.md"},
+				{From: "docs/guide.md", To: "[[Nowhere]]", Broken: true},
+				{From: "docs/guide.md", To: "docs/deep.md"},
+				{From: "docs/guide.md", To: "docs/missing.md", Broken: true},
+				{From: "index.md", To: "docs/guide.md"},
+				{From: "index.md", To: "external:example.com"},
+				{From: "index.md", To: "setup-notes.md"},
+			},
+		},
+		{
+			name:      "depth from start",
+			opts:      Options{Start: "docs/guide.md", Depth: 1},
+			wantNodes: []string{"docs/deep.md", "docs/guide.md"},
+			wantEdges: []Edge{{From: "docs/guide.md", To: "docs/deep.md"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := Build(dir, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var nodes []string
+			for _, n := range g.Nodes {
+				nodes = append(nodes, n.ID)
+			}
+			if diff := cmp.Diff(tt.wantNodes, nodes); diff != "" {
+				t.Errorf("nodes mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEdges, g.Edges); diff != "" {
+				t.Errorf("edges mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := Build(dir, Options{Start: "nope.md"}); err == nil {
+		t.Error("Build with an unknown start succeeded")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	g, err := Build(writeTree(t, tree), Options{External: true, Broken: true, Start: "docs/guide.md", Depth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dot bytes.Buffer
+	if err := g.Write(&dot, FormatDOT); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\t\"docs/missing.md\" [label=\"docs/missing.md\", style=dashed, color=red, fontcolor=red];\n",
+		"\tsubgraph cluster_external {\n",
+		"\t\t\"external:go.dev\" [label=\"go.dev\", shape=ellipse];\n",
+		"\t\"docs/guide.md\" -> \"docs/missing.md\" [color=red, style=dashed];\n",
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT output is missing %q:\n%s", want, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	if err := g.Write(&mermaid, FormatMermaid); err != nil {
+		t.Fatal(err)
+	}
+	want := `flowchart LR
+	n0["[[Nowhere]]"]:::missing
+	n1["docs/deep.md"]
+	n2["docs/guide.md"]
+	n3["docs/missing.md"]:::missing
+	n5["index.md"]
+	subgraph external [External]
+		n4(["go.dev"])
+	end
+	n1 --> n4
+	n1 --> n5
+	n2 -.-> n0
+	n2 --> n1
+	n2 -.-> n3
+	n5 --> n2
+	linkStyle 2,4 stroke:red
+	classDef missing stroke:red,color:red,stroke-dasharray:4
+`
+	if diff := cmp.Diff(want, mermaid.String()); diff != "" {
+		t.Errorf("Mermaid mismatch (-want +got):\n%s", diff)
+	}
+
+	var graphml bytes.Buffer
+	if err := g.Write(&graphml, FormatGraphML); err != nil {
+		t.Fatal(err)
+	}
+	var parsed graphML
+	if err := xml.Unmarshal(graphml.Bytes(), &parsed); err != nil {
+		t.Fatalf("GraphML does not parse: %v\n%s", err, graphml.String())
+	}
+	if len(parsed.Graph.Nodes) != len(g.Nodes) || len(parsed.Graph.Edges) != len(g.Edges) {
+		t.Errorf("GraphML has %d nodes and %d edges, want %d and %d", len(parsed.Graph.Nodes), len(parsed.Graph.Edges), len(g.Nodes), len(g.Edges))
+	}
+
+	if err := g.Write(&bytes.Buffer{}, "png"); err == nil {
+		t.Error("Write with an unknown format succeeded")
+	}
+}
@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatGraphML = "graphml"
+)
+
+var Formats = []string{FormatDOT, FormatMermaid, FormatGraphML}
+
+// Write renders g to w in format.
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatDOT:
+		return g.writeDOT(w)
+	case FormatMermaid:
+		return g.writeMermaid(w)
+	case FormatGraphML:
+		return g.writeGraphML(w)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func (g *Graph) writeDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph docs {\n\trankdir=LR;\n\tnode [shape=box];\n")
+
+	var external []Node
+	for _, n := range g.Nodes {
+		switch n.Kind {
+		case KindExternal:
+			external = append(external, n)
+		case KindMissing:
+			fmt.Fprintf(&b, "\t%s [label=%s, style=dashed, color=red, fontcolor=red];\n", strconv.Quote(n.ID), strconv.Quote(n.Label))
+		default:
+			fmt.Fprintf(&b, "\t%s [label=%s];\n", strconv.Quote(n.ID), strconv.Quote(n.Label))
+		}
+	}
+	if len(external) > 0 {
+		b.WriteString("\tsubgraph cluster_external {\n\t\tlabel=\"External\";\n\t\tstyle=dashed;\n")
+		for _, n := range external {
+			fmt.Fprintf(&b, "\t\t%s [label=%s, shape=ellipse];\n", strconv.Quote(n.ID), strconv.Quote(n.Label))
+		}
+		b.WriteString("\t}\n")
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s", strconv.Quote(e.From), strconv.Quote(e.To))
+		if e.Broken {
+			b.WriteString(" [color=red, style=dashed]")
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidLabel quotes a label for a Mermaid node, which cannot contain a
+// double quote.
+func mermaidLabel(label string) string {
+	return `"` + strings.ReplaceAll(label, `"`, "#quot;") + `"`
+}
+
+func (g *Graph) writeMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	// Mermaid IDs must be simple words, so nodes are numbered.
+	ids := map[string]string{}
+	var external []Node
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		switch n.Kind {
+		case KindExternal:
+			external = append(external, n)
+		case KindMissing:
+			fmt.Fprintf(&b, "\t%s[%s]:::missing\n", ids[n.ID], mermaidLabel(n.Label))
+		default:
+			fmt.Fprintf(&b, "\t%s[%s]\n", ids[n.ID], mermaidLabel(n.Label))
+		}
+	}
+	if len(external) > 0 {
+		b.WriteString("\tsubgraph external [External]\n")
+		for _, n := range external {
+			fmt.Fprintf(&b, "\t\t%s([%s])\n", ids[n.ID], mermaidLabel(n.Label))
+		}
+		b.WriteString("\tend\n")
+	}
+
+	var broken []string
+	for i, e := range g.Edges {
+		arrow := "-->"
+		if e.Broken {
+			arrow = "-.->"
+			broken = append(broken, strconv.Itoa(i))
+		}
+		fmt.Fprintf(&b, "\t%s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+	if len(broken) > 0 {
+		fmt.Fprintf(&b, "\tlinkStyle %s stroke:red\n", strings.Join(broken, ","))
+		b.WriteString("\tclassDef missing stroke:red,color:red,stroke-dasharray:4\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+func (g *Graph) writeGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "kind", For: "node", Name: "kind", Type: "string"},
+			{ID: "broken", For: "edge", Name: "broken", Type: "boolean"},
+		},
+		Graph: graphMLGraph{ID: "docs", EdgeDefault: "directed"},
+	}
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   n.ID,
+			Data: []graphMLData{{Key: "label", Value: n.Label}, {Key: "kind", Value: string(n.Kind)}},
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: e.From,
+			Target: e.To,
+			Data:   []graphMLData{{Key: "broken", Value: strconv.FormatBool(e.Broken)}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
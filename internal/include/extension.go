@@ -0,0 +1,69 @@
+package include
+
+import (
+	"io"
+	"sync"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+)
+
+// Extension resolves include directives while parsing with goldmark.
+// Because goldmark renders nodes against the source they were parsed
+// from, the extension wraps both the parser, which parses the resolved
+// document, and the renderer, which renders such a document against the
+// resolved source instead of the one it is given.
+type Extension struct {
+	// Path is the document being converted; includes are relative to its
+	// directory.
+	Path string
+	// OnError is called when the includes cannot be resolved, in which
+	// case the document is parsed as it is.
+	OnError func(error)
+}
+
+// New returns an extension that resolves includes relative to the
+// document at path.
+func New(path string) *Extension {
+	return &Extension{Path: path}
+}
+
+func (e *Extension) Extend(m goldmark.Markdown) {
+	sources := &sync.Map{}
+	m.SetParser(&includeParser{Parser: m.Parser(), extension: e, sources: sources})
+	m.SetRenderer(&includeRenderer{Renderer: m.Renderer(), sources: sources})
+}
+
+type includeParser struct {
+	parser.Parser
+	extension *Extension
+	sources   *sync.Map
+}
+
+func (p *includeParser) Parse(reader text.Reader, opts ...parser.ParseOption) ast.Node {
+	resolved, err := ResolveSource(p.extension.Path, reader.Source())
+	if err != nil {
+		if p.extension.OnError != nil {
+			p.extension.OnError(err)
+		}
+		return p.Parser.Parse(reader, opts...)
+	}
+	doc := p.Parser.Parse(text.NewReader(resolved), opts...)
+	p.sources.Store(doc, resolved)
+	return doc
+}
+
+type includeRenderer struct {
+	renderer.Renderer
+	sources *sync.Map
+}
+
+func (r *includeRenderer) Render(w io.Writer, source []byte, n ast.Node) error {
+	if resolved, ok := r.sources.LoadAndDelete(n); ok {
+		source = resolved.([]byte)
+	}
+	return r.Renderer.Render(w, source, n)
+}
@@ -0,0 +1,345 @@
+// Package include resolves include directives, which transclude markdown
+// files, sections of them or code files into a document:
+//
+//	<!-- include: shared/setup.md#install -->
+//	<!-- include: example/main.go lines=5-12 -->
+//	{{< include "shared/setup.md" >}}
+//
+// A directive is a block of its own. Markdown is included without its
+// front matter, with its own includes resolved and with its relative links
+// and images rebased onto the including document. A #fragment selects the
+// section under the heading with that ID. Other files become fenced code
+// blocks, in the language of the lang attribute or of their extension.
+// lines=first-last keeps a 1-based inclusive range of the lines of the file
+// or section, where either end may be left out.
+package include
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/codeblock"
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+var (
+	commentRegex   = regexp.MustCompile(`^\s*<!--\s*include:\s*(.*?)\s*-->\s*$`)
+	shortcodeRegex = regexp.MustCompile(`^\s*\{\{<\s*include\s+(.*?)\s*>\}\}\s*$`)
+	frontMatter    = regexp.MustCompile(`(?s)\A---\r?\n.*?\r?\n---\r?\n`)
+)
+
+// languages maps file extensions to the language of their fenced blocks.
+var languages = map[string]string{
+	".c":    "c",
+	".cpp":  "cpp",
+	".css":  "css",
+	".go":   "go",
+	".html": "html",
+	".java": "java",
+	".js":   "javascript",
+	".json": "json",
+	".py":   "python",
+	".rb":   "ruby",
+	".rs":   "rust",
+	".sh":   "sh",
+	".sql":  "sql",
+	".toml": "toml",
+	".ts":   "typescript",
+	".yaml": "yaml",
+	".yml":  "yaml",
+}
+
+// Directive is an include directive found in a document.
+type Directive struct {
+	Span     mdast.Span
+	Target   string
+	Fragment string
+	Attrs    map[string]string
+}
+
+// Directives returns the include directives of source in document order.
+// Spans cover whole lines, including the final newline.
+func Directives(source []byte) []Directive {
+	doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+	var result []Directive
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		var re *regexp.Regexp
+		switch n.Kind() {
+		case ast.KindHTMLBlock:
+			re = commentRegex
+		case ast.KindParagraph:
+			re = shortcodeRegex
+		default:
+			return ast.WalkContinue, nil
+		}
+		lines := n.Lines()
+		if lines.Len() != 1 {
+			return ast.WalkSkipChildren, nil
+		}
+		line := lines.At(0)
+		m := re.FindSubmatch(line.Value(source))
+		if m == nil {
+			return ast.WalkSkipChildren, nil
+		}
+		info := codeblock.ParseInfo(string(m[1]))
+		target, fragment, _ := strings.Cut(strings.Trim(info.Language, `"`), "#")
+		stop := line.Stop
+		if i := bytes.IndexByte(source[line.Start:], '\n'); i >= 0 {
+			stop = line.Start + i + 1
+		}
+		result = append(result, Directive{
+			Span:     mdast.Span{Start: line.Start, Stop: stop},
+			Target:   target,
+			Fragment: fragment,
+			Attrs:    info.Attrs,
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return result
+}
+
+// Resolve reads the document at path and returns it with its includes
+// resolved.
+func Resolve(path string) ([]byte, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveSource(path, source)
+}
+
+// ResolveSource resolves the includes of source, the content of the
+// document at path. Targets are relative to the directory of path.
+func ResolveSource(path string, source []byte) ([]byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(abs, source, []string{abs})
+}
+
+func resolve(path string, source []byte, stack []string) ([]byte, error) {
+	var out []byte
+	last := 0
+	for _, d := range Directives(source) {
+		included, err := include(path, d, stack)
+		if err != nil {
+			line := mdast.PositionOf(source, d.Span.Start).Line
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		out = append(out, source[last:d.Span.Start]...)
+		out = append(out, indent(included, mdast.LinePrefix(source, d.Span.Start))...)
+		last = d.Span.Stop
+	}
+	return append(out, source[last:]...), nil
+}
+
+// include returns the content a directive in the document at path stands
+// for, ending in a newline.
+func include(path string, d Directive, stack []string) ([]byte, error) {
+	if d.Target == "" {
+		return nil, fmt.Errorf("include directive without a file")
+	}
+	target := d.Target
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), filepath.FromSlash(target))
+	}
+	for i, p := range stack {
+		if p == target {
+			cycle := append(append([]string{}, stack[i:]...), target)
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if !files.IsMarkdown(target) {
+		if content, err = lineRange(content, d.Attrs["lines"]); err != nil {
+			return nil, err
+		}
+		language := d.Attrs["lang"]
+		if language == "" {
+			language = languages[strings.ToLower(filepath.Ext(target))]
+		}
+		return []byte(fence(language, content)), nil
+	}
+
+	content = frontMatter.ReplaceAll(content, nil)
+	if d.Fragment != "" {
+		if content, err = section(content, d.Fragment); err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Target, err)
+		}
+	}
+	if content, err = lineRange(content, d.Attrs["lines"]); err != nil {
+		return nil, err
+	}
+	content, err = resolve(target, content, append(stack, target))
+	if err != nil {
+		return nil, err
+	}
+	content = rebase(content, target, path)
+	// The directive's own line ends the content; blank lines around it
+	// stay as the including document has them.
+	content = bytes.TrimRight(content, "\n")
+	if len(content) > 0 {
+		content = append(content, '\n')
+	}
+	return content, nil
+}
+
+// section returns the heading with the given ID and everything up to the
+// next heading of the same or a higher level.
+func section(source []byte, id string) ([]byte, error) {
+	doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+	var start *ast.Heading
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			continue
+		}
+		if start != nil && heading.Level <= start.Level {
+			return source[lineStart(source, start.Pos()):lineStart(source, heading.Pos())], nil
+		}
+		if value, ok := heading.AttributeString("id"); ok && start == nil {
+			if b, ok := value.([]byte); ok && string(b) == id {
+				start = heading
+			}
+		}
+	}
+	if start == nil {
+		return nil, fmt.Errorf("no heading with ID %q", id)
+	}
+	return source[lineStart(source, start.Pos()):], nil
+}
+
+// lineRange returns the lines of content in spec, such as "3-7", "3-" or
+// "-7", or content itself when spec is empty.
+func lineRange(content []byte, spec string) ([]byte, error) {
+	if spec == "" {
+		return content, nil
+	}
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	first, last := 1, len(lines)
+	from, to, isRange := strings.Cut(spec, "-")
+	var err error
+	if from != "" {
+		if first, err = strconv.Atoi(from); err != nil {
+			return nil, fmt.Errorf("invalid line range %q", spec)
+		}
+	}
+	switch {
+	case !isRange:
+		last = first
+	case to != "":
+		if last, err = strconv.Atoi(to); err != nil {
+			return nil, fmt.Errorf("invalid line range %q", spec)
+		}
+	}
+	if first < 1 || last < first || last > len(lines) {
+		return nil, fmt.Errorf("line range %q is outside the %d lines of the file", spec, len(lines))
+	}
+	return bytes.Join(lines[first-1:last], nil), nil
+}
+
+// fence wraps content in a fenced code block longer than any backtick run
+// in it.
+func fence(language string, content []byte) string {
+	longest, run := 0, 0
+	for _, c := range content {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	marker := strings.Repeat("`", max(3, longest+1))
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	return marker + language + "\n" + string(content) + marker + "\n"
+}
+
+// rebase rewrites the relative link and image destinations of content,
+// written for the document at from, to point at the same files from the
+// document at to, including those of link reference definitions.
+func rebase(content []byte, from, to string) []byte {
+	fromDir, toDir := filepath.Dir(from), filepath.Dir(to)
+	if fromDir == toDir {
+		return content
+	}
+	var out []byte
+	last := 0
+	for _, link := range mdast.Links(core.NewGoldmark().Parser().Parse(text.NewReader(content)), content) {
+		spans, ok := mdast.LinkSpansOf(content, link.Node)
+		if !ok || spans.Destination.Start < last {
+			continue
+		}
+		target, ok := files.LocalTarget(from, link.URL)
+		if !ok {
+			continue
+		}
+		u, err := url.Parse(link.URL)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(toDir, target)
+		if err != nil {
+			continue
+		}
+		u.Path = filepath.ToSlash(rel)
+		u.RawPath = ""
+		out = append(out, content[last:spans.Destination.Start]...)
+		out = append(out, u.String()...)
+		last = spans.Destination.Stop
+	}
+	return append(out, content[last:]...)
+}
+
+func lineStart(source []byte, pos int) int {
+	return bytes.LastIndexByte(source[:pos], '\n') + 1
+}
+
+// indent prefixes every line of content after the first, which takes the
+// place of a directive that already follows the prefix.
+func indent(content []byte, prefix string) []byte {
+	if prefix == "" {
+		return content
+	}
+	var out []byte
+	for i, line := range bytes.SplitAfter(content, []byte("\n")) {
+		switch {
+		case len(line) == 0:
+		case i == 0:
+			out = append(out, line...)
+		case string(line) == "\n":
+			out = append(out, strings.TrimRight(prefix, " ")...)
+			out = append(out, line...)
+		default:
+			out = append(out, prefix...)
+			out = append(out, line...)
+		}
+	}
+	return out
+}
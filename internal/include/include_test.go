@@ -0,0 +1,112 @@
+package include
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/goldmark"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/testutil"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"guide.md": "# Guide\n\n<!-- include: shared/setup.md#install -->\n\n" +
+			"> {{< include \"shared/main.go\" lines=\"2-3\" >}}\n\n" +
+			"```md\n<!-- include: shared/setup.md -->\n```\n",
+		"shared/setup.md":   "---\ntitle: Setup\n---\n# Setup\n\n## Install\n\nSee [notes](notes.md#top) and ![logo](../img/logo.png).\n\n<!-- include: snippet.md -->\n\n### Details\n\nMore.\n\n## Usage\n\nUse it.\n",
+		"shared/snippet.md": "Read [the FAQ](faq.md).",
+		"shared/main.go":    "package main\n\nfunc main() {}\n",
+	})
+
+	got, err := Resolve(filepath.Join(dir, "guide.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Guide\n\n" +
+		"## Install\n\nSee [notes](shared/notes.md#top) and ![logo](img/logo.png).\n\n" +
+		"Read [the FAQ](shared/faq.md).\n\n" +
+		"### Details\n\nMore.\n\n" +
+		"> ```go\n>\n> func main() {}\n> ```\n\n" +
+		"```md\n<!-- include: shared/setup.md -->\n```\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Resolve mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolveDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"guide.md":       "# Guide\n\n<!-- include: shared/logo.md -->\n",
+		"shared/logo.md": "![logo][img] and [home][]\n\n[img]: ../img.png \"Logo\"\n[home]: <index.md#top>\n",
+	})
+
+	got, err := Resolve(filepath.Join(dir, "guide.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Guide\n\n![logo][img] and [home][]\n\n[img]: img.png \"Logo\"\n[home]: <shared/index.md#top>\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Resolve mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"a.md":       "<!-- include: b.md -->\n",
+		"b.md":       "<!-- include: a.md -->\n",
+		"section.md": "<!-- include: c.md#missing -->\n",
+		"lines.md":   "<!-- include: c.md lines=5-9 -->\n",
+		"c.md":       "# C\n",
+	})
+	for name, want := range map[string]string{
+		"a.md":       "include cycle: " + filepath.Join(dir, "a.md") + " -> " + filepath.Join(dir, "b.md") + " -> " + filepath.Join(dir, "a.md"),
+		"section.md": `no heading with ID "missing"`,
+		"lines.md":   `line range "5-9" is outside the 1 lines of the file`,
+	} {
+		_, err := Resolve(filepath.Join(dir, name))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Resolve(%s) error = %v, want it to contain %q", name, err, want)
+		}
+	}
+}
+
+func TestExtension(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"doc.md":   "# Doc\n\n<!-- include: part.md -->\n",
+		"part.md":  "Included *text*.\n",
+		"cycle.md": "<!-- include: cycle.md -->\n",
+	})
+
+	source, err := os.ReadFile(filepath.Join(dir, "doc.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	md := core.NewGoldmark(goldmark.WithExtensions(New(filepath.Join(dir, "doc.md"))))
+	if err := md.Convert(source, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("<h1 id=\"doc\">Doc</h1>\n<p>Included <em>text</em>.</p>\n", buf.String()); diff != "" {
+		t.Errorf("Convert mismatch (-want +got):\n%s", diff)
+	}
+
+	var reported error
+	ext := New(filepath.Join(dir, "cycle.md"))
+	ext.OnError = func(err error) { reported = err }
+	buf.Reset()
+	if err := core.NewGoldmark(goldmark.WithExtensions(ext)).Convert([]byte("<!-- include: cycle.md -->\n"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if reported == nil {
+		t.Error("include cycle was not reported")
+	}
+}
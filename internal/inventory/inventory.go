@@ -0,0 +1,204 @@
+// Package inventory collects the links of a documentation tree: every
+// external URL with the places that reference it, and for each markdown
+// document the other documents that link to it.
+package inventory
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// Reference is a link in a document. Path is relative to the inventory
+// root and slash separated.
+type Reference struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Text   string `json:"text,omitempty"`
+}
+
+type URL struct {
+	URL        string      `json:"url"`
+	Count      int         `json:"count"`
+	References []Reference `json:"references"`
+}
+
+type Document struct {
+	Path      string      `json:"path"`
+	Backlinks []Reference `json:"backlinks"`
+}
+
+type Inventory struct {
+	Root      string     `json:"root"`
+	URLs      []URL      `json:"urls"`
+	Documents []Document `json:"documents"`
+}
+
+// Orphans returns the documents no other document links to.
+func (inv *Inventory) Orphans() []string {
+	var orphans []string
+	for _, doc := range inv.Documents {
+		if len(doc.Backlinks) == 0 {
+			orphans = append(orphans, doc.Path)
+		}
+	}
+	return orphans
+}
+
+// Build walks the markdown files under root.
+func Build(root string) (*Inventory, error) {
+	paths, err := files.Markdown([]string{root})
+	if err != nil {
+		return nil, err
+	}
+
+	urls := map[string]*URL{}
+	backlinks := map[string][]Reference{}
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		rel, err := relPath(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := backlinks[rel]; !ok {
+			backlinks[rel] = nil
+		}
+
+		doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+		for _, link := range links(doc, source) {
+			pos := mdast.PositionOf(source, link.offset)
+			ref := Reference{Path: rel, Line: pos.Line, Column: pos.Column, Text: link.text}
+
+			if normalized, ok := Normalize(link.url); ok {
+				u := urls[normalized]
+				if u == nil {
+					u = &URL{URL: normalized}
+					urls[normalized] = u
+				}
+				u.Count++
+				u.References = append(u.References, ref)
+				continue
+			}
+
+			target, ok := files.LocalTarget(path, link.url)
+			if !ok || !files.IsMarkdown(target) {
+				continue
+			}
+			targetRel, err := relPath(root, target)
+			if err != nil || targetRel == rel || strings.HasPrefix(targetRel, "../") {
+				continue
+			}
+			backlinks[targetRel] = append(backlinks[targetRel], ref)
+		}
+	}
+
+	inv := &Inventory{Root: filepath.ToSlash(root), URLs: []URL{}, Documents: []Document{}}
+	for _, u := range urls {
+		inv.URLs = append(inv.URLs, *u)
+	}
+	sort.Slice(inv.URLs, func(i, j int) bool { return inv.URLs[i].URL < inv.URLs[j].URL })
+
+	for path, refs := range backlinks {
+		// Links to missing documents are not backlinks of anything.
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(path))); err != nil {
+			continue
+		}
+		if refs == nil {
+			refs = []Reference{}
+		}
+		inv.Documents = append(inv.Documents, Document{Path: path, Backlinks: refs})
+	}
+	sort.Slice(inv.Documents, func(i, j int) bool { return inv.Documents[i].Path < inv.Documents[j].Path })
+	return inv, nil
+}
+
+func relPath(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Normalize returns the canonical form of an absolute URL: lower case
+// scheme and host, no default port, no fragment and "/" for an empty
+// path. Relative URLs report false.
+func Normalize(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme == "" {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+	if u.Host != "" && u.Path == "" && u.Opaque == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), true
+}
+
+type link struct {
+	url    string
+	text   string
+	offset int
+}
+
+// links returns the links, images and autolinks of doc, including
+// reference links, with the byte offset where each starts.
+func links(doc ast.Node, source []byte) []link {
+	var result []link
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.AutoLink:
+			offset := blockStart(node)
+			if span, ok := mdast.AutoLinkSpan(source, node); ok {
+				offset = span.Start
+			}
+			result = append(result, link{url: string(node.URL(source)), text: string(node.Label(source)), offset: offset})
+		case *ast.Link:
+			result = append(result, link{url: string(node.Destination), text: mdast.PlainText(source, node), offset: start(node)})
+		case *ast.Image:
+			result = append(result, link{url: string(node.Destination), text: mdast.PlainText(source, node), offset: start(node)})
+		}
+		return ast.WalkContinue, nil
+	})
+	return result
+}
+
+func start(n ast.Node) int {
+	if pos := n.Pos(); pos >= 0 {
+		return pos
+	}
+	return blockStart(n)
+}
+
+// blockStart returns the start of the block containing n.
+func blockStart(n ast.Node) int {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Type() == ast.TypeBlock && p.Lines().Len() > 0 {
+			return p.Lines().At(0).Start
+		}
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package inventory
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalize(t *testing.T) {
+	for raw, want := range map[string]string{
+		"HTTPS://Example.COM":           "https://example.com/",
+		"https://example.com:443/a#top": "https://example.com/a",
+		"http://example.com:8080/?q=1":  "http://example.com:8080/?q=1",
+		"mailto:me@example.com":         "mailto:me@example.com",
+	} {
+		if got, ok := Normalize(raw); !ok || got != want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", raw, got, ok, want)
+		}
+	}
+	if _, ok := Normalize("docs/guide.md"); ok {
+		t.Error("relative URL normalized")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"README.md":      "# Readme\n\nSee [the guide](docs/guide.md#install) and https://Example.com.\n",
+		"docs/guide.md":  "# Guide\n\n[Example](https://example.com/) and [ref][r], [home](../README.md), [self](#guide).\n\n[r]: https://go.dev\n",
+		"docs/orphan.md": "# Orphan\n\n[Guide](guide.md)\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inv, err := Build(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantURLs := []URL{
+		{URL: "https://example.com/", Count: 2, References: []Reference{
+			{Path: "README.md", Line: 3, Column: 44, Text: "https://Example.com"},
+			{Path: "docs/guide.md", Line: 3, Column: 1, Text: "Example"},
+		}},
+		{URL: "https://go.dev/", Count: 1, References: []Reference{
+			{Path: "docs/guide.md", Line: 3, Column: 37, Text: "ref"},
+		}},
+	}
+	if diff := cmp.Diff(wantURLs, inv.URLs); diff != "" {
+		t.Errorf("URLs mismatch (-want +got):\n%s", diff)
+	}
+
+	wantDocs := []Document{
+		{Path: "README.md", Backlinks: []Reference{{Path: "docs/guide.md", Line: 3, Column: 47, Text: "home"}}},
+		{Path: "docs/guide.md", Backlinks: []Reference{
+			{Path: "README.md", Line: 3, Column: 5, Text: "the guide"},
+			{Path: "docs/orphan.md", Line: 3, Column: 1, Text: "Guide"},
+		}},
+		{Path: "docs/orphan.md", Backlinks: []Reference{}},
+	}
+	if diff := cmp.Diff(wantDocs, inv.Documents); diff != "" {
+		t.Errorf("documents mismatch (-want +got):\n%s", diff)
+	}
+
+	var report bytes.Buffer
+	if err := inv.WriteMarkdown(&report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| <https://example.com/> | 2 | `README.md:3`, `docs/guide.md:3` |",
+		"### `docs/guide.md`\n\n- `README.md:3` \"the guide\"\n",
+		"## Orphaned pages\n\n- `docs/orphan.md`\n",
+	} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, report.String())
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (inv *Inventory) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inv)
+}
+
+var cellEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+func locations(refs []Reference) string {
+	parts := make([]string, len(refs))
+	for i, ref := range refs {
+		parts[i] = fmt.Sprintf("`%s:%d`", ref.Path, ref.Line)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteMarkdown writes the inventory as a markdown report page.
+func (inv *Inventory) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Link inventory\n\n")
+	fmt.Fprintf(&b, "Documents under `%s`: %d. External URLs: %d.\n\n", inv.Root, len(inv.Documents), len(inv.URLs))
+
+	b.WriteString("## URLs\n\n")
+	if len(inv.URLs) == 0 {
+		b.WriteString("No external URLs.\n\n")
+	} else {
+		b.WriteString("| URL | Count | Referenced from |\n| --- | ---: | --- |\n")
+		for _, u := range inv.URLs {
+			fmt.Fprintf(&b, "| <%s> | %d | %s |\n", cellEscaper.Replace(u.URL), u.Count, cellEscaper.Replace(locations(u.References)))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Backlinks\n\n")
+	if len(inv.Orphans()) == len(inv.Documents) {
+		b.WriteString("No document links to another.\n\n")
+	}
+	for _, doc := range inv.Documents {
+		if len(doc.Backlinks) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "### `%s`\n\n", doc.Path)
+		for _, ref := range doc.Backlinks {
+			fmt.Fprintf(&b, "- `%s:%d`", ref.Path, ref.Line)
+			if ref.Text != "" {
+				fmt.Fprintf(&b, " %q", ref.Text)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Orphaned pages\n\n")
+	orphans := inv.Orphans()
+	if len(orphans) == 0 {
+		b.WriteString("Every document has at least one backlink.\n")
+	}
+	for _, path := range orphans {
+		fmt.Fprintf(&b, "- `%s`\n", path)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,248 @@
+// Package linkcheck validates the local links of markdown documents: that
+// relative link and image destinations exist on disk and that #fragments
+// name a heading in the target document.
+package linkcheck
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// maxCandidates bounds the number of files considered when suggesting a
+// replacement for a missing path.
+const maxCandidates = 5000
+
+// Problem is a broken local link. Suggestion is the destination that was
+// probably meant, or empty when nothing is close.
+type Problem struct {
+	Span       mdast.Span
+	Message    string
+	Suggestion string
+}
+
+// Check validates the inline links, images and link reference definitions
+// in root, the parsed form of the document at path. Reference links are
+// checked through their definition, which problems point at. Anchors
+// within the document are checked against root itself, so unsaved edits
+// are honoured.
+func Check(path string, source []byte, root ast.Node) []Problem {
+	c := checker{path: path, ids: HeadingIDs(root)}
+	var problems []Problem
+	for _, link := range mdast.Links(root, source) {
+		if _, ok := link.Node.(*ast.AutoLink); ok {
+			continue
+		}
+		if p, ok := c.check(link.URL); ok {
+			p.Span = link.Span
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+type checker struct {
+	path       string
+	ids        []string
+	candidates []string
+}
+
+func (c *checker) check(destination string) (Problem, bool) {
+	if strings.HasPrefix(destination, "#") {
+		fragment, err := url.PathUnescape(destination[1:])
+		if err != nil || fragment == "" {
+			return Problem{}, false
+		}
+		return checkAnchor(c.ids, fragment, "", "this document")
+	}
+
+	// Relative paths cannot be resolved for documents without a path,
+	// such as unsaved editor buffers.
+	if c.path == "" {
+		return Problem{}, false
+	}
+	target, ok := files.LocalTarget(c.path, destination)
+	if !ok {
+		return Problem{}, false
+	}
+	u, _ := url.Parse(destination)
+
+	info, err := os.Stat(target)
+	if err != nil {
+		p := Problem{Message: fmt.Sprintf("link target %s does not exist", u.Path)}
+		if suggestion := c.suggestPath(u.Path); suggestion != "" {
+			u.Path = suggestion
+			p.Suggestion = u.String()
+			p.Message += fmt.Sprintf("; did you mean %s?", p.Suggestion)
+		}
+		return p, true
+	}
+
+	if u.Fragment == "" || info.IsDir() || !files.IsMarkdown(target) {
+		return Problem{}, false
+	}
+	ids, err := targetIDs(target, info)
+	if err != nil {
+		return Problem{}, false
+	}
+	return checkAnchor(ids, u.Fragment, u.EscapedPath(), u.Path)
+}
+
+// checkAnchor reports a problem when fragment is not one of ids. prefix is
+// the path part of the destination, kept in the suggestion.
+func checkAnchor(ids []string, fragment, prefix, name string) (Problem, bool) {
+	for _, id := range ids {
+		if id == fragment {
+			return Problem{}, false
+		}
+	}
+	p := Problem{Message: fmt.Sprintf("anchor #%s not found in %s", fragment, name)}
+	if id := closest(fragment, ids); id != "" {
+		p.Suggestion = prefix + "#" + id
+		p.Message += fmt.Sprintf("; did you mean %s?", p.Suggestion)
+	}
+	return p, true
+}
+
+// suggestPath looks for the file a missing relative path most likely
+// meant among the files below the document's directory. A file with the
+// same name wins if it is the only one, which catches moved files;
+// otherwise the closest path by edit distance does.
+func (c *checker) suggestPath(missing string) string {
+	if c.candidates == nil {
+		c.candidates = candidates(filepath.Dir(c.path))
+	}
+
+	missing = path.Clean(missing)
+	var sameName []string
+	for _, candidate := range c.candidates {
+		if filepath.Base(candidate) == filepath.Base(missing) {
+			sameName = append(sameName, candidate)
+		}
+	}
+	if len(sameName) == 1 {
+		return sameName[0]
+	}
+	return closest(missing, c.candidates)
+}
+
+// candidates lists the files below dir as slash separated paths relative
+// to it, skipping hidden directories.
+func candidates(dir string) []string {
+	result := []string{}
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if len(result) >= maxCandidates {
+			return filepath.SkipAll
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			result = append(result, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return result
+}
+
+// HeadingIDs returns the IDs of the headings in root, as generated by the
+// parser's auto heading ID option.
+func HeadingIDs(root ast.Node) []string {
+	var ids []string
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && n.Kind() == ast.KindHeading {
+			if id, ok := n.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					ids = append(ids, string(b))
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return ids
+}
+
+type cachedIDs struct {
+	modTime time.Time
+	size    int64
+	ids     []string
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]cachedIDs{}
+)
+
+// targetIDs returns the heading IDs of the markdown file at path, parsing
+// it only when it changed since the last call.
+func targetIDs(path string, info fs.FileInfo) ([]string, error) {
+	cacheMu.Lock()
+	cached, ok := cache[path]
+	cacheMu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.ids, nil
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ids := HeadingIDs(core.NewGoldmark().Parser().Parse(text.NewReader(source)))
+
+	cacheMu.Lock()
+	cache[path] = cachedIDs{modTime: info.ModTime(), size: info.Size(), ids: ids}
+	cacheMu.Unlock()
+	return ids, nil
+}
+
+// closest returns the candidate nearest to s by edit distance, or "" when
+// none is within roughly a third of the length of s.
+func closest(s string, candidates []string) string {
+	best, bestDistance := "", len(s)/3+2
+	for _, candidate := range candidates {
+		if d := distance(s, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between a and b, counted in bytes.
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,74 @@
+package linkcheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "docs", "guide.md"), "# Guide\n\n## Install\n\n## Install\n\n## Usage Notes\n")
+	writeFile(t, filepath.Join(dir, "docs", "img", "logo.png"), "")
+
+	source := []byte(`# Readme
+
+[ok](docs/guide.md#usage-notes) [dup](docs/guide.md#install-1) [dir](docs/)
+[self](#readme) ![logo](docs/img/logo.png) [web](https://example.com/missing.md)
+[typo](docs/gide.md)
+[moved](guide.md#install)
+[anchor](docs/guide.md#instal)
+[self typo](#redme)
+![image](docs/img/log.png)
+[unknown](docs/nothing-like-this-at-all.txt)
+[by reference][gone] and [again][gone], [fine][guide]
+
+[gone]: ./gone.md "Gone"
+[guide]:
+  docs/guide.md#install
+`)
+	path := filepath.Join(dir, "README.md")
+	root := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+	var got []string
+	for _, p := range Check(path, source, root) {
+		got = append(got, string(p.Span.Value(source))+" => "+p.Suggestion)
+	}
+	want := []string{
+		"[typo](docs/gide.md) => docs/guide.md",
+		"[moved](guide.md#install) => docs/guide.md#install",
+		"[anchor](docs/guide.md#instal) => docs/guide.md#install",
+		"[self typo](#redme) => #readme",
+		"![image](docs/img/log.png) => docs/img/logo.png",
+		"[unknown](docs/nothing-like-this-at-all.txt) => ",
+		`[gone]: ./gone.md "Gone" => `,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("problems mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckWithoutPath(t *testing.T) {
+	source := []byte("# Title\n\n[a](missing.md) [b](#title) [c](#nope)\n")
+	root := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+	problems := Check("", source, root)
+	if len(problems) != 1 || problems[0].Suggestion != "" || string(problems[0].Span.Value(source)) != "[c](#nope)" {
+		t.Errorf("got %+v, want only the #nope anchor", problems)
+	}
+}
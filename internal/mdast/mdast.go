@@ -0,0 +1,426 @@
+package mdast
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Span is a range of byte offsets into a source. Stop is exclusive.
+type Span struct {
+	Start int
+	Stop  int
+}
+
+func (s Span) Contains(offset int) bool {
+	return s.Start <= offset && offset <= s.Stop
+}
+
+func (s Span) Value(source []byte) []byte {
+	return source[s.Start:s.Stop]
+}
+
+// Position is a 1-based line and column. Column counts runes.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// PositionOf converts a byte offset in source to a line and column.
+func PositionOf(source []byte, offset int) Position {
+	if offset > len(source) {
+		offset = len(source)
+	}
+	lineStart := bytes.LastIndexByte(source[:offset], '\n') + 1
+	return Position{
+		Line:   bytes.Count(source[:offset], []byte("\n")) + 1,
+		Column: utf8.RuneCount(source[lineStart:offset]) + 1,
+	}
+}
+
+// LinePrefix returns the container prefix, such as "> " or list
+// indentation, before pos on its line.
+func LinePrefix(source []byte, pos int) string {
+	return string(source[bytes.LastIndexByte(source[:pos], '\n')+1 : pos])
+}
+
+// AutoLinkSpan returns the span of n in source, including the angle
+// brackets of a <url> autolink. Linkified autolinks report a position just
+// before their text, so the label is searched for from there.
+func AutoLinkSpan(source []byte, n *ast.AutoLink) (Span, bool) {
+	label := n.Label(source)
+	pos := n.Pos()
+	if pos < 0 || len(label) == 0 {
+		return Span{}, false
+	}
+
+	i := bytes.Index(source[pos:], label)
+	if i < 0 {
+		return Span{}, false
+	}
+	span := Span{Start: pos + i, Stop: pos + i + len(label)}
+	if span.Start > 0 && source[span.Start-1] == '<' && span.Stop < len(source) && source[span.Stop] == '>' {
+		span.Start--
+		span.Stop++
+	}
+	return span, true
+}
+
+// LinkSpans locates the parts of an inline link or image in source.
+type LinkSpans struct {
+	// Full covers the whole link, from "[" or "![" through ")".
+	Full Span
+	// Text covers the link text between the brackets.
+	Text Span
+	// Destination covers the destination, without angle brackets.
+	Destination Span
+	// Title covers the title without its quotes or parentheses. It is
+	// empty when there is none.
+	Title Span
+}
+
+// InlineLinkSpans returns the spans of an *ast.Link or *ast.Image written
+// as an inline link. Reference links and other nodes report false.
+func InlineLinkSpans(source []byte, n ast.Node) (LinkSpans, bool) {
+	switch n.(type) {
+	case *ast.Link, *ast.Image:
+	default:
+		return LinkSpans{}, false
+	}
+	if n.Pos() < 0 {
+		return LinkSpans{}, false
+	}
+	return ScanInlineLink(source, n.Pos())
+}
+
+// LinkSpansOf returns the spans of an inline link, image or link reference
+// definition, the nodes whose destination is written where they are.
+func LinkSpansOf(source []byte, n ast.Node) (LinkSpans, bool) {
+	if def, ok := n.(*ast.LinkReferenceDefinition); ok {
+		return DefinitionSpans(source, def)
+	}
+	return InlineLinkSpans(source, n)
+}
+
+// ScanInlineLink scans the inline link or image, [text](destination
+// "title"), that starts at start in source, following CommonMark: the
+// text may hold balanced or escaped brackets and code spans, the
+// destination is <anything but line endings and angle brackets> or a run
+// of non-space characters whose unescaped parentheses are balanced, and a
+// title must be separated from it by whitespace. Whitespace around the
+// destination and title may include line endings.
+func ScanInlineLink(source []byte, start int) (LinkSpans, bool) {
+	var spans LinkSpans
+	i := start
+	if i < len(source) && source[i] == '!' {
+		i++
+	}
+	if i >= len(source) || source[i] != '[' {
+		return spans, false
+	}
+
+	spans.Text.Start = i + 1
+	depth := 0
+loop:
+	for ; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '`':
+			i = skipCodeSpan(source, i) - 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				break loop
+			}
+		}
+	}
+	if i >= len(source) {
+		return spans, false
+	}
+	spans.Text.Stop = i
+	i++
+
+	if i >= len(source) || source[i] != '(' {
+		return spans, false
+	}
+	i = skipSpace(source, i+1)
+	var ok bool
+	if spans.Destination, i, ok = scanDestination(source, i); !ok {
+		return spans, false
+	}
+
+	if j := skipSpace(source, i); j > i && j < len(source) && (source[j] == '"' || source[j] == '\'' || source[j] == '(') {
+		if spans.Title, i, ok = scanTitle(source, j); !ok {
+			return spans, false
+		}
+	}
+	i = skipSpace(source, i)
+	if i >= len(source) || source[i] != ')' {
+		return spans, false
+	}
+	spans.Full = Span{Start: start, Stop: i + 1}
+	return spans, true
+}
+
+// scanDestination returns the span of the link destination at i, without
+// angle brackets, and the offset just past it.
+func scanDestination(source []byte, i int) (Span, int, bool) {
+	if i < len(source) && source[i] == '<' {
+		start := i + 1
+		for i++; i < len(source); i++ {
+			switch source[i] {
+			case '\\':
+				if i+1 < len(source) && isASCIIPunct(source[i+1]) {
+					i++
+				}
+			case '<', '\n', '\r':
+				return Span{}, i, false
+			case '>':
+				return Span{Start: start, Stop: i}, i + 1, true
+			}
+		}
+		return Span{}, i, false
+	}
+
+	start := i
+	parens := 0
+loop:
+	for ; i < len(source); i++ {
+		switch c := source[i]; {
+		case c <= ' ' || c == 0x7f:
+			break loop
+		case c == '\\':
+			if i+1 < len(source) && isASCIIPunct(source[i+1]) {
+				i++
+			}
+		case c == '(':
+			parens++
+		case c == ')':
+			if parens == 0 {
+				break loop
+			}
+			parens--
+		}
+	}
+	if parens != 0 {
+		return Span{}, i, false
+	}
+	return Span{Start: start, Stop: i}, i, true
+}
+
+// scanTitle returns the span of the title that opens at i, without its
+// delimiters, and the offset just past it. A parenthesized title may not
+// hold unescaped parentheses.
+func scanTitle(source []byte, i int) (Span, int, bool) {
+	closer := source[i]
+	if closer == '(' {
+		closer = ')'
+	}
+	start := i + 1
+	for i++; i < len(source) && source[i] != closer; i++ {
+		switch {
+		case source[i] == '\\':
+			i++
+		case closer == ')' && source[i] == '(':
+			return Span{}, i, false
+		}
+	}
+	if i >= len(source) {
+		return Span{}, i, false
+	}
+	return Span{Start: start, Stop: i}, i + 1, true
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// DefinitionSpans returns the spans of a link reference definition, as in
+// [label]: destination "title". Full runs from "[" to the end of the
+// definition and Text covers the label.
+func DefinitionSpans(source []byte, n *ast.LinkReferenceDefinition) (LinkSpans, bool) {
+	var spans LinkSpans
+	lines := n.Lines()
+	if lines.Len() == 0 {
+		return spans, false
+	}
+	i := lines.At(0).Start
+	for i < len(source) && (source[i] == ' ' || source[i] == '\t') {
+		i++
+	}
+	if i >= len(source) || source[i] != '[' {
+		return spans, false
+	}
+	spans.Full.Start = i
+	spans.Text.Start = i + 1
+	for i++; i < len(source) && source[i] != ']'; i++ {
+		if source[i] == '\\' {
+			i++
+		}
+	}
+	if i+1 >= len(source) || source[i+1] != ':' {
+		return spans, false
+	}
+	spans.Text.Stop = i
+
+	var ok bool
+	if spans.Destination, i, ok = scanDestination(source, skipSpace(source, i+2)); !ok {
+		return spans, false
+	}
+	if j := skipSpace(source, i); j > i && j < len(source) && (source[j] == '"' || source[j] == '\'' || source[j] == '(') {
+		if title, _, ok := scanTitle(source, j); ok {
+			spans.Title = title
+		}
+	}
+	last := lines.At(lines.Len() - 1)
+	spans.Full.Stop = last.Start + len(bytes.TrimRight(last.Value(source), " \t\r\n"))
+	return spans, true
+}
+
+// skipCodeSpan returns the offset just past the code span that starts at
+// i, or just past the opening backticks when the span is not closed.
+func skipCodeSpan(source []byte, i int) int {
+	n := 0
+	for i+n < len(source) && source[i+n] == '`' {
+		n++
+	}
+	for j := i + n; j < len(source); {
+		if source[j] != '`' {
+			j++
+			continue
+		}
+		m := 0
+		for j+m < len(source) && source[j+m] == '`' {
+			m++
+		}
+		if m == n {
+			return j + m
+		}
+		j += m
+	}
+	return i + n
+}
+
+func skipSpace(source []byte, i int) int {
+	for i < len(source) && (source[i] == ' ' || source[i] == '\t' || source[i] == '\n' || source[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// PlainText returns the text content of n and its descendants.
+func PlainText(source []byte, n ast.Node) string {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(t.Value)
+		case *ast.AutoLink:
+			buf.Write(t.Label(source))
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.String()
+}
+
+// Link is a link, image, autolink or link reference definition found in a
+// document. Label is empty for images and is the label of definitions.
+type Link struct {
+	Node  ast.Node
+	Span  Span
+	URL   string
+	Label string
+}
+
+// Links returns the autolinks, inline links, images and link reference
+// definitions under root in source order. Reference links such as [x][ref]
+// have no destination of their own and are skipped; their definitions
+// stand for them.
+func Links(root ast.Node, source []byte) []Link {
+	var links []Link
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.AutoLink:
+			if span, ok := AutoLinkSpan(source, node); ok {
+				links = append(links, Link{
+					Node:  node,
+					Span:  span,
+					URL:   string(node.URL(source)),
+					Label: string(node.Label(source)),
+				})
+			}
+		case *ast.Link:
+			if spans, ok := InlineLinkSpans(source, node); ok {
+				links = append(links, Link{
+					Node:  node,
+					Span:  spans.Full,
+					URL:   string(node.Destination),
+					Label: PlainText(source, node),
+				})
+			}
+		case *ast.Image:
+			if spans, ok := InlineLinkSpans(source, node); ok {
+				links = append(links, Link{
+					Node: node,
+					Span: spans.Full,
+					URL:  string(node.Destination),
+				})
+			}
+		case *ast.LinkReferenceDefinition:
+			if spans, ok := DefinitionSpans(source, node); ok {
+				links = append(links, Link{
+					Node:  node,
+					Span:  spans.Full,
+					URL:   string(node.Destination),
+					Label: string(node.Label),
+				})
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
+
+// TextRuns returns the source spans of the plain text under root, outside
+// links, images, autolinks, code spans and raw HTML. Adjacent text nodes
+// are joined because goldmark splits text at characters such as "_" that
+// may turn out not to be emphasis.
+func TextRuns(root ast.Node) []Span {
+	var runs []Span
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindLink, ast.KindImage, ast.KindAutoLink, ast.KindCodeSpan, ast.KindRawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		t, ok := n.(*ast.Text)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		if len(runs) > 0 && runs[len(runs)-1].Stop == t.Segment.Start {
+			runs[len(runs)-1].Stop = t.Segment.Stop
+		} else {
+			runs = append(runs, Span{Start: t.Segment.Start, Stop: t.Segment.Stop})
+		}
+		return ast.WalkContinue, nil
+	})
+	return runs
+}
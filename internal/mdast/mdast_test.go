@@ -0,0 +1,160 @@
+package mdast
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+)
+
+// parts is LinkSpans resolved to the text they cover.
+type parts struct {
+	Full, Text, Destination, Title string
+}
+
+func partsOf(source []byte, spans LinkSpans) parts {
+	return parts{
+		Full:        string(spans.Full.Value(source)),
+		Text:        string(spans.Text.Value(source)),
+		Destination: string(spans.Destination.Value(source)),
+		Title:       string(spans.Title.Value(source)),
+	}
+}
+
+func TestScanInlineLink(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *parts
+	}{
+		{"plain", "[a](b) after", &parts{"[a](b)", "a", "b", ""}},
+		{"image", "![alt](img.png)", &parts{"![alt](img.png)", "alt", "img.png", ""}},
+		{"empty destination", "[a]()", &parts{"[a]()", "a", "", ""}},
+		{"nested brackets", "[a [b [c]] d](e)", &parts{"[a [b [c]] d](e)", "a [b [c]] d", "e", ""}},
+		{"escaped bracket", `[a \] b](c)`, &parts{`[a \] b](c)`, `a \] b`, "c", ""}},
+		{"bracket in code span", "[a `]` b](c)", &parts{"[a `]` b](c)", "a `]` b", "c", ""}},
+		{"unclosed text", "[a [b](c)", nil},
+		{"space before parenthesis", "[a] (b)", nil},
+
+		{"balanced parentheses", "[Go](https://en.wikipedia.org/wiki/Go_(lang)).", &parts{"[Go](https://en.wikipedia.org/wiki/Go_(lang))", "Go", "https://en.wikipedia.org/wiki/Go_(lang)", ""}},
+		{"escaped closing parenthesis", `[a](b\)c)`, &parts{`[a](b\)c)`, "a", `b\)c`, ""}},
+		{"escaped opening parenthesis", `[a](b\(c)`, &parts{`[a](b\(c)`, "a", `b\(c`, ""}},
+		{"unbalanced parenthesis", "[a](b(c)", nil},
+		{"escaped space does not continue", `[a](b\ c)`, nil},
+		{"space inside destination", "[a](b c)", nil},
+		{"surrounding spaces", "[a](  b\t)", &parts{"[a](  b\t)", "a", "b", ""}},
+
+		{"angle destination", "[a](<b c>)", &parts{"[a](<b c>)", "a", "b c", ""}},
+		{"angle destination with parenthesis", "[a](<b)c>)", &parts{"[a](<b)c>)", "a", "b)c", ""}},
+		{"angle destination escaped bracket", `[a](<b\>c>)`, &parts{`[a](<b\>c>)`, "a", `b\>c`, ""}},
+		{"empty angle destination", "[a](<>)", &parts{"[a](<>)", "a", "", ""}},
+		{"angle destination with angle bracket", "[a](<b<c>)", nil},
+		{"angle destination across lines", "[a](<b\nc>)", nil},
+		{"unclosed angle destination", "[a](<b)", nil},
+
+		{"double quoted title", `[a](b "t")`, &parts{`[a](b "t")`, "a", "b", "t"}},
+		{"single quoted title", "[a](b 't')", &parts{"[a](b 't')", "a", "b", "t"}},
+		{"parenthesized title", "[a](b (t))", &parts{"[a](b (t))", "a", "b", "t"}},
+		{"escaped quote in title", `[a](b "t \" q")`, &parts{`[a](b "t \" q")`, "a", "b", `t \" q`}},
+		{"nested parenthesis in title", "[a](b (t(x)))", nil},
+		{"title after angle destination", `[a](<b> "t")`, &parts{`[a](<b> "t")`, "a", "b", "t"}},
+		{"title without space", `[a](<b>"t")`, nil},
+		{"quote in destination", `[a](b"t")`, &parts{`[a](b"t")`, "a", `b"t"`, ""}},
+		{"unclosed title", `[a](b "t)`, nil},
+		{"text after title", `[a](b "t" c)`, nil},
+
+		{"multi-line text", "[a\nb](c)", &parts{"[a\nb](c)", "a\nb", "c", ""}},
+		{"multi-line destination and title", "[a](\n  c\n  \"t\"\n)", &parts{"[a](\n  c\n  \"t\"\n)", "a", "c", "t"}},
+		{"multi-line title", "[a](c 'one\ntwo')", &parts{"[a](c 'one\ntwo')", "a", "c", "one\ntwo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := []byte(tt.input)
+			spans, ok := ScanInlineLink(source, 0)
+			if tt.want == nil {
+				if ok {
+					t.Errorf("ScanInlineLink(%q) = %+v, want no link", tt.input, partsOf(source, spans))
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("ScanInlineLink(%q) found no link", tt.input)
+			}
+			if diff := cmp.Diff(*tt.want, partsOf(source, spans)); diff != "" {
+				t.Errorf("ScanInlineLink(%q) mismatch (-want +got):\n%s", tt.input, diff)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	source := []byte("# T\n\nSee <https://go.dev>, [a](b.md \"t\"), ![i](i.png), [r][ref] and `[c](d)`.\n\n" +
+		"> [quoted](q.md)\n\n[ref]:\n  <./r e f.md>\n  'Ref'\n")
+	root := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+	var got []string
+	for _, link := range Links(root, source) {
+		got = append(got, string(link.Span.Value(source))+" => "+link.URL)
+	}
+	want := []string{
+		"<https://go.dev> => https://go.dev",
+		`[a](b.md "t") => b.md`,
+		"![i](i.png) => i.png",
+		"[quoted](q.md) => q.md",
+		"[ref]:\n  <./r e f.md>\n  'Ref' => ./r e f.md",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Links mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, link := range Links(root, source) {
+		if def, ok := link.Node.(*ast.LinkReferenceDefinition); ok {
+			spans, ok := LinkSpansOf(source, def)
+			if !ok {
+				t.Fatal("LinkSpansOf found no spans for the definition")
+			}
+			if diff := cmp.Diff(parts{"[ref]:\n  <./r e f.md>\n  'Ref'", "ref", "./r e f.md", "Ref"}, partsOf(source, spans)); diff != "" {
+				t.Errorf("definition spans mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+}
+
+func TestPositionOf(t *testing.T) {
+	source := []byte("ab\nçé x\n")
+	for _, tt := range []struct {
+		offset int
+		want   Position
+	}{
+		{0, Position{1, 1}},
+		{2, Position{1, 3}},
+		{3, Position{2, 1}},
+		{8, Position{2, 4}},
+		{100, Position{3, 1}},
+	} {
+		if got := PositionOf(source, tt.offset); got != tt.want {
+			t.Errorf("PositionOf(%d) = %+v, want %+v", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestLinePrefix(t *testing.T) {
+	source := []byte("# T\n\n> - item\n>   ```sh\n")
+	for _, tt := range []struct {
+		offset int
+		want   string
+	}{
+		{0, ""},
+		{bytes.Index(source, []byte("item")), "> - "},
+		{bytes.Index(source, []byte("```")), ">   "},
+	} {
+		if got := LinePrefix(source, tt.offset); got != tt.want {
+			t.Errorf("LinePrefix(%d) = %q, want %q", tt.offset, got, tt.want)
+		}
+	}
+}
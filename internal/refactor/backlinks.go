@@ -0,0 +1,189 @@
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+const (
+	BacklinksStart = "<!-- backlinks:start -->"
+	BacklinksEnd   = "<!-- backlinks:end -->"
+)
+
+type backlink struct {
+	title string
+	path  string
+}
+
+// Backlinks plans writing a "## Backlinks" section between BacklinksStart
+// and BacklinksEnd to each markdown file under root, listing the documents
+// that link to it by title with relative links. An existing section is
+// replaced where it is, a new one is appended at the end of the file, and
+// the section is removed from documents nothing links to. Links inside
+// the sections themselves are ignored, so running it again plans nothing.
+func Backlinks(root string) (*Plan, error) {
+	paths, err := files.Markdown([]string{root})
+	if err != nil {
+		return nil, err
+	}
+
+	// The section is written to the normalized source, and add writes it
+	// back with the line endings of the file.
+	raws := map[string][]byte{}
+	sources := map[string][]byte{}
+	titles := map[string]string{}
+	inbound := map[string][]backlink{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		source, _ := eol.Normalize(raw)
+		raws[abs], sources[abs] = raw, source
+
+		body := source
+		if start, stop, ok := backlinksSection(source); ok {
+			body = append(append([]byte{}, source[:start]...), source[stop:]...)
+		}
+		pc := parser.NewContext()
+		doc := core.NewGoldmark().Parser().Parse(text.NewReader(body), parser.WithContext(pc))
+		titles[abs] = documentTitle(doc, body, pc, abs)
+
+		linked := map[string]bool{}
+		_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if link, ok := n.(*ast.Link); ok && entering {
+				if target, ok := files.LocalTarget(abs, string(link.Destination)); ok && target != abs {
+					linked[target] = true
+				}
+			}
+			return ast.WalkContinue, nil
+		})
+		for target := range linked {
+			inbound[target] = append(inbound[target], backlink{path: abs})
+		}
+	}
+
+	plan := &Plan{}
+	for _, path := range paths {
+		abs, _ := filepath.Abs(path)
+		var links []backlink
+		for _, b := range inbound[abs] {
+			links = append(links, backlink{title: titles[b.path], path: b.path})
+		}
+		sort.Slice(links, func(i, j int) bool {
+			if links[i].title != links[j].title {
+				return links[i].title < links[j].title
+			}
+			return links[i].path < links[j].path
+		})
+		plan.add(Change{Path: path, Before: raws[abs], After: withBacklinks(sources[abs], abs, links)})
+	}
+	return plan, nil
+}
+
+// backlinksSection returns the span of the generated section, from its
+// start marker to the end of the line of its end marker.
+func backlinksSection(source []byte) (int, int, bool) {
+	start := bytes.Index(source, []byte(BacklinksStart))
+	if start < 0 {
+		return 0, 0, false
+	}
+	end := bytes.Index(source[start:], []byte(BacklinksEnd))
+	if end < 0 {
+		return 0, 0, false
+	}
+	stop := start + end + len(BacklinksEnd)
+	if i := bytes.IndexByte(source[stop:], '\n'); i >= 0 {
+		stop += i + 1
+	} else {
+		stop = len(source)
+	}
+	return start, stop, true
+}
+
+// documentTitle prefers the front matter title, then the first heading,
+// then the file name without its extension.
+func documentTitle(doc ast.Node, source []byte, pc parser.Context, path string) string {
+	if t, ok := meta.Get(pc)["title"].(string); ok && strings.TrimSpace(t) != "" {
+		return strings.TrimSpace(t)
+	}
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if heading, ok := n.(*ast.Heading); ok {
+			if t := mdast.PlainText(source, heading); t != "" {
+				return t
+			}
+		}
+	}
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+var linkTextEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`)
+
+func renderBacklinks(path string, links []backlink) string {
+	var b strings.Builder
+	b.WriteString(BacklinksStart + "\n## Backlinks\n\n")
+	for _, link := range links {
+		rel, err := filepath.Rel(filepath.Dir(path), link.path)
+		if err != nil {
+			rel = link.path
+		}
+		destination := filepath.ToSlash(rel)
+		if strings.ContainsAny(destination, " ()<>") {
+			destination = "<" + destination + ">"
+		}
+		fmt.Fprintf(&b, "- [%s](%s)\n", linkTextEscaper.Replace(link.title), destination)
+	}
+	b.WriteString(BacklinksEnd + "\n")
+	return b.String()
+}
+
+// withBacklinks returns source with its section listing links, touching
+// nothing outside the section and the blank line that separates it from
+// the content before it.
+func withBacklinks(source []byte, path string, links []backlink) []byte {
+	start, stop, ok := backlinksSection(source)
+	if len(links) == 0 {
+		if !ok {
+			return source
+		}
+		before, after := source[:start], source[stop:]
+		if bytes.HasSuffix(before, []byte("\n\n")) && (len(after) == 0 || after[0] == '\n') {
+			before = before[:len(before)-1]
+		}
+		return append(append([]byte{}, before...), after...)
+	}
+
+	section := renderBacklinks(path, links)
+	if ok {
+		return applyEdits(source, []edit{{span: mdast.Span{Start: start, Stop: stop}, newText: section}})
+	}
+	out := append([]byte{}, source...)
+	switch {
+	case len(out) == 0:
+	case bytes.HasSuffix(out, []byte("\n\n")):
+	case bytes.HasSuffix(out, []byte("\n")):
+		out = append(out, '\n')
+	default:
+		out = append(out, "\n\n"...)
+	}
+	return append(out, section...)
+}
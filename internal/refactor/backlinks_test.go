@@ -0,0 +1,92 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gkwa/bravewaldo/internal/testutil"
+)
+
+func TestBacklinks(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"index.md":        "# Index\n\nSee [the first note](notes/first.md#top) and [second](notes/second.md).",
+		"notes/first.md":  "---\ntitle: \"First [note]\"\n---\n# Heading\n\nLinks [second](second.md).\n",
+		"notes/second.md": "# Second\n\nBody.\n\n<!-- backlinks:start -->\n## Backlinks\n\n- [Stale](stale.md)\n<!-- backlinks:end -->\n\nFooter.\n",
+		"lonely.md":       "# Lonely\n\n<!-- backlinks:start -->\n## Backlinks\n\n- [Index](index.md)\n<!-- backlinks:end -->\n",
+	})
+
+	plan, err := Backlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		// Not linked to, but links in the lonely section do not count.
+		"index.md": "# Index\n\nSee [the first note](notes/first.md#top) and [second](notes/second.md).",
+		"notes/first.md": "---\ntitle: \"First [note]\"\n---\n# Heading\n\nLinks [second](second.md).\n\n" +
+			"<!-- backlinks:start -->\n## Backlinks\n\n- [Index](../index.md)\n<!-- backlinks:end -->\n",
+		"notes/second.md": "# Second\n\nBody.\n\n" +
+			"<!-- backlinks:start -->\n## Backlinks\n\n- [First \\[note\\]](first.md)\n- [Index](../index.md)\n<!-- backlinks:end -->\n\nFooter.\n",
+		"lonely.md": "# Lonely\n",
+	}
+	for name, content := range want {
+		if diff := cmp.Diff(content, readFile(t, dir, name)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+
+	plan, err = Backlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range plan.Changes {
+		t.Errorf("second run changes %s:\n%s", c.Path, c.After)
+	}
+}
+
+func TestBacklinksLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"index.md": "\ufeff# Index\r\n\r\nSee [the note](note.md).\r\n",
+		"note.md":  "\ufeff# Note\r\n\r\nBody.",
+	})
+
+	plan, err := Backlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	want := "\ufeff# Note\r\n\r\nBody.\r\n\r\n<!-- backlinks:start -->\r\n## Backlinks\r\n\r\n- [Index](index.md)\r\n<!-- backlinks:end -->"
+	if diff := cmp.Diff(want, readFile(t, dir, "note.md")); diff != "" {
+		t.Errorf("note.md mismatch (-want +got):\n%s", diff)
+	}
+
+	plan, err = Backlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range plan.Changes {
+		t.Errorf("second run changes %s:\n%q", c.Path, c.After)
+	}
+
+	testutil.WriteFiles(t, dir, map[string]string{"other.md": "[note](note.md)\n"})
+	plan, err = Backlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.SetLineEnding("\n")
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	want = "\ufeff# Note\n\nBody.\n\n<!-- backlinks:start -->\n## Backlinks\n\n- [Index](index.md)\n- [other](other.md)\n<!-- backlinks:end -->"
+	if diff := cmp.Diff(want, readFile(t, dir, "note.md")); diff != "" {
+		t.Errorf("note.md with LF mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,168 @@
+package refactor
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/linkcheck"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// RenameHeading plans changing the text of a heading in the markdown file
+// at path to newText. heading is either the current heading text or its
+// ID prefixed with "#". Renaming can change the IDs of later headings with
+// the same text, since duplicates get numeric suffixes, so every heading
+// ID that changes is updated in the #anchor links of the file itself and
+// of the markdown files under root that link to it.
+func RenameHeading(root, path, heading, newText string) (*Plan, error) {
+	if strings.ContainsAny(newText, "\r\n") {
+		return nil, fmt.Errorf("heading text must be a single line")
+	}
+	newText = strings.TrimSpace(newText)
+	if newText == "" {
+		return nil, fmt.Errorf("heading text must not be empty")
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	tree := parse(source)
+	headings := headingNodes(tree)
+	oldIDs := linkcheck.HeadingIDs(tree)
+
+	target, err := findHeading(source, headings, oldIDs, heading)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	lines := target.Lines()
+	if lines.Len() == 0 {
+		return nil, fmt.Errorf("%s: heading %q has no text to rename", path, heading)
+	}
+	renamed := applyEdits(source, []edit{{
+		span:    mdast.Span{Start: lines.At(0).Start, Stop: lines.At(lines.Len() - 1).Stop},
+		newText: newText,
+	}})
+
+	newIDs := linkcheck.HeadingIDs(parse(renamed))
+	if len(newIDs) != len(oldIDs) {
+		return nil, fmt.Errorf("renaming the heading changed the document structure")
+	}
+	changed := map[string]string{}
+	for i := range oldIDs {
+		if oldIDs[i] != newIDs[i] {
+			changed[oldIDs[i]] = newIDs[i]
+		}
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := files.Markdown([]string{root})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	plan.add(Change{Path: path, Before: source, After: reanchor(renamed, absPath, absPath, changed)})
+	for _, doc := range docs {
+		absDoc, err := filepath.Abs(doc)
+		if err != nil {
+			return nil, err
+		}
+		if absDoc == absPath {
+			continue
+		}
+		source, err := os.ReadFile(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", doc, err)
+		}
+		plan.add(Change{Path: doc, Before: source, After: reanchor(source, absDoc, absPath, changed)})
+	}
+	return plan, nil
+}
+
+func headingNodes(doc ast.Node) []*ast.Heading {
+	var headings []*ast.Heading
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			headings = append(headings, h)
+		}
+		return ast.WalkContinue, nil
+	})
+	return headings
+}
+
+// findHeading returns the heading whose ID is "#"-prefixed heading, or
+// whose text is heading. Text that matches several headings is an error.
+func findHeading(source []byte, headings []*ast.Heading, ids []string, heading string) (*ast.Heading, error) {
+	if id, ok := strings.CutPrefix(heading, "#"); ok {
+		for i, h := range headings {
+			if i < len(ids) && ids[i] == id {
+				return h, nil
+			}
+		}
+		return nil, fmt.Errorf("no heading with ID %q", id)
+	}
+
+	var matches []*ast.Heading
+	var matchIDs []string
+	for i, h := range headings {
+		if mdast.PlainText(source, h) == heading {
+			matches = append(matches, h)
+			matchIDs = append(matchIDs, "#"+ids[i])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no heading %q", heading)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("%d headings %q, choose one by ID: %s", len(matches), heading, strings.Join(matchIDs, ", "))
+}
+
+// reanchor rewrites the fragments of links and link reference definitions
+// in source, the document at from, that point into the document at target
+// and use an ID in changed. Only the fragment bytes change.
+func reanchor(source []byte, from, target string, changed map[string]string) []byte {
+	if len(changed) == 0 {
+		return source
+	}
+
+	var edits []edit
+	for _, link := range mdast.Links(parse(source), source) {
+		spans, ok := mdast.LinkSpansOf(source, link.Node)
+		if !ok {
+			continue
+		}
+		destination := spans.Destination.Value(source)
+		hash := strings.LastIndexByte(string(destination), '#')
+		if hash < 0 {
+			continue
+		}
+
+		if hash > 0 || from != target {
+			resolved, ok := files.LocalTarget(from, link.URL)
+			if !ok || resolved != target {
+				continue
+			}
+		}
+		fragment, err := url.PathUnescape(string(destination[hash+1:]))
+		if err != nil {
+			continue
+		}
+		if id, ok := changed[fragment]; ok {
+			start := spans.Destination.Start + hash + 1
+			edits = append(edits, edit{span: mdast.Span{Start: start, Stop: spans.Destination.Stop}, newText: id})
+		}
+	}
+	return applyEdits(source, edits)
+}
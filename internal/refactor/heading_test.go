@@ -0,0 +1,95 @@
+package refactor
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gkwa/bravewaldo/internal/testutil"
+)
+
+func TestRenameHeading(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"guide.md": "# Guide\n\n## Install\n\nFirst.\n\n## Install ##\n\nSecond, see [first](#install) and [second](#install-1).\n\n" +
+			"Usage\n-----\n\n[usage](guide.md#usage)\n",
+		"README.md": "[install](guide.md#install), [again](guide.md#install-1), [usage](./guide.md#usage) and [local](#install).\n",
+	})
+	path := filepath.Join(dir, "guide.md")
+
+	plan, err := RenameHeading(dir, path, "#install", "Setup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	// The second Install heading loses its -1 suffix.
+	want := "# Guide\n\n## Setup\n\nFirst.\n\n## Install ##\n\nSecond, see [first](#setup) and [second](#install).\n\n" +
+		"Usage\n-----\n\n[usage](guide.md#usage)\n"
+	if diff := cmp.Diff(want, readFile(t, dir, "guide.md")); diff != "" {
+		t.Errorf("guide.md mismatch (-want +got):\n%s", diff)
+	}
+	want = "[install](guide.md#setup), [again](guide.md#install), [usage](./guide.md#usage) and [local](#install).\n"
+	if diff := cmp.Diff(want, readFile(t, dir, "README.md")); diff != "" {
+		t.Errorf("README.md mismatch (-want +got):\n%s", diff)
+	}
+
+	plan, err = RenameHeading(dir, path, "Usage", "How to use")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, dir, "guide.md"); !strings.Contains(got, "How to use\n-----\n\n[usage](guide.md#how-to-use)\n") {
+		t.Errorf("setext heading not renamed:\n%s", got)
+	}
+	if got := readFile(t, dir, "README.md"); !strings.Contains(got, "[usage](./guide.md#how-to-use)") {
+		t.Errorf("README.md not updated:\n%s", got)
+	}
+}
+
+func TestRenameHeadingDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"guide.md":  "# Guide\n\n## Install\n\nSee [above][top] and [install][here].\n\n[top]: #guide\n[here]: #install\n",
+		"README.md": "[Install][i] or [the guide][g].\n\n[i]: guide.md#install \"Install\"\n[g]:\n  <./guide.md#guide>\n",
+	})
+
+	plan, err := RenameHeading(dir, filepath.Join(dir, "guide.md"), "Install", "Setup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"guide.md":  "# Guide\n\n## Setup\n\nSee [above][top] and [install][here].\n\n[top]: #guide\n[here]: #setup\n",
+		"README.md": "[Install][i] or [the guide][g].\n\n[i]: guide.md#setup \"Install\"\n[g]:\n  <./guide.md#guide>\n",
+	}
+	for name, content := range want {
+		if diff := cmp.Diff(content, readFile(t, dir, name)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestRenameHeadingErrors(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{"a.md": "# A\n\n## B\n\n## B\n"})
+	path := filepath.Join(dir, "a.md")
+
+	for _, tt := range []struct{ heading, newText, want string }{
+		{"B", "C", "choose one by ID: #b, #b-1"},
+		{"Missing", "C", "no heading"},
+		{"#nope", "C", "no heading with ID"},
+		{"A", "two\nlines", "single line"},
+	} {
+		if _, err := RenameHeading(dir, path, tt.heading, tt.newText); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("RenameHeading(%q, %q) error = %v, want %q", tt.heading, tt.newText, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package refactor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/linkcheck"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// piece is part of a merged document: a whole file or text of the index
+// between the lists it links from.
+type piece struct {
+	path    string
+	content []byte
+	ids     []string
+	style   eol.Style
+}
+
+// Merge plans concatenating the markdown files at paths, in order, into
+// output. Their headings are shifted down by shift levels, the front
+// matter of the first file is kept and the others are dropped. Links
+// between the merged files become #anchor links to the merged headings,
+// and other relative links are rebased onto output. An output of "-"
+// stands for stdout: links are rebased onto the current directory and the
+// change has no Before.
+func Merge(paths []string, output string, shift int) (*Plan, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files to merge")
+	}
+	var pieces []piece
+	var frontMatter []byte
+	for i, path := range paths {
+		p, fm, err := readPiece(path, shift)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			frontMatter = fm
+		}
+		pieces = append(pieces, p)
+	}
+	return merge(pieces, frontMatter, output)
+}
+
+// MergeIndex plans the inverse of Split: the markdown file at index is
+// written to output with each list that only links to local markdown
+// files replaced by those files, merged as by Merge. Headings of the
+// linked files are shifted down by shift levels; those of the index are
+// not.
+func MergeIndex(index, output string, shift int) (*Plan, error) {
+	raw, err := os.ReadFile(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", index, err)
+	}
+	source, style := eol.Normalize(raw)
+	absIndex, err := filepath.Abs(index)
+	if err != nil {
+		return nil, err
+	}
+	var frontMatter []byte
+	if m := frontMatterRegex.FindIndex(source); m != nil {
+		frontMatter = source[:m[1]]
+	}
+
+	doc := parse(source)
+	ids := linkcheck.HeadingIDs(doc)
+	var pieces []piece
+	text := func(start, stop int) {
+		content := source[start:stop]
+		if m := frontMatterRegex.FindIndex(content); m != nil && start == 0 {
+			content = content[m[1]:]
+		}
+		n := len(linkcheck.HeadingIDs(parse(content)))
+		pieces = append(pieces, piece{path: absIndex, content: content, ids: ids[:n], style: style})
+		ids = ids[n:]
+	}
+
+	last := 0
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		list, ok := n.(*ast.List)
+		if !ok {
+			continue
+		}
+		targets, ok := linkedFiles(source, absIndex, list)
+		if !ok {
+			continue
+		}
+		start := lineStart(source, list.Pos())
+		text(last, start)
+		for _, target := range targets {
+			p, _, err := readPiece(target, shift)
+			if err != nil {
+				return nil, err
+			}
+			pieces = append(pieces, p)
+		}
+		last = len(source)
+		if next := list.NextSibling(); next != nil {
+			last = lineStart(source, next.Pos())
+		}
+	}
+	if last == 0 {
+		return nil, fmt.Errorf("%s has no lists of links to markdown files", index)
+	}
+	text(last, len(source))
+	return merge(pieces, frontMatter, output)
+}
+
+// ReadOrder returns the paths listed in the file at path, one per line and
+// relative to its directory. Blank lines and lines starting with # are
+// skipped.
+func ReadOrder(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(path), filepath.FromSlash(line))
+		}
+		paths = append(paths, line)
+	}
+	return paths, scanner.Err()
+}
+
+// linkedFiles returns the markdown files a list links to when each of its
+// items is nothing but a link to one.
+func linkedFiles(source []byte, path string, list *ast.List) ([]string, bool) {
+	var targets []string
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		block := item.FirstChild()
+		if block == nil || block != item.LastChild() {
+			return nil, false
+		}
+		link, ok := block.FirstChild().(*ast.Link)
+		if !ok || link != block.LastChild() {
+			return nil, false
+		}
+		u, err := url.Parse(string(link.Destination))
+		if err != nil || u.Fragment != "" {
+			return nil, false
+		}
+		target, ok := files.LocalTarget(path, string(link.Destination))
+		if !ok || !files.IsMarkdown(target) {
+			return nil, false
+		}
+		targets = append(targets, target)
+	}
+	return targets, len(targets) > 0
+}
+
+// readPiece reads a markdown file to merge and returns it without its
+// front matter, which is returned separately, and with its headings
+// shifted.
+func readPiece(path string, shift int) (piece, []byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return piece{}, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	source, style := eol.Normalize(raw)
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return piece{}, nil, err
+	}
+	var frontMatter []byte
+	if m := frontMatterRegex.FindIndex(source); m != nil {
+		frontMatter, source = source[:m[1]], source[m[1]:]
+	}
+	content := shiftHeadings(source, shift)
+	return piece{path: abs, content: content, ids: linkcheck.HeadingIDs(parse(source)), style: style}, frontMatter, nil
+}
+
+func merge(pieces []piece, frontMatter []byte, output string) (*Plan, error) {
+	absOutput, err := filepath.Abs(output)
+	if err != nil {
+		return nil, err
+	}
+
+	join := func(contents [][]byte) []byte {
+		var parts [][]byte
+		for _, c := range contents {
+			if c = bytes.Trim(c, "\n"); len(c) > 0 {
+				parts = append(parts, c)
+			}
+		}
+		out := append(append([]byte{}, frontMatter...), bytes.Join(parts, []byte("\n\n"))...)
+		return append(out, '\n')
+	}
+	contents := make([][]byte, len(pieces))
+	for i, p := range pieces {
+		contents[i] = p.content
+	}
+
+	// Merged headings get new IDs, with suffixes where texts repeat.
+	ids := linkcheck.HeadingIDs(parse(join(contents)))
+	anchors := map[string]string{}
+	first := map[string]string{}
+	i := 0
+	for _, p := range pieces {
+		for _, id := range p.ids {
+			if i >= len(ids) {
+				return nil, fmt.Errorf("merging changed the headings of %s", p.path)
+			}
+			anchors[p.path+"#"+id] = ids[i]
+			if _, ok := first[p.path]; !ok {
+				first[p.path] = ids[i]
+			}
+			i++
+		}
+	}
+	if i != len(ids) {
+		return nil, fmt.Errorf("merging changed the document structure")
+	}
+
+	for i, p := range pieces {
+		contents[i] = mergeLinks(p.content, p.path, absOutput, anchors, first)
+	}
+
+	var before []byte
+	if output != "-" {
+		before, err = os.ReadFile(output)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", output, err)
+		}
+	}
+	// The merged document is written like the first of its pieces.
+	style := pieces[0].style
+	style.FinalNewline = true
+	plan := &Plan{}
+	plan.addStyled(Change{Path: output, Before: before, After: join(contents)}, style)
+	return plan, nil
+}
+
+// mergeLinks rewrites the links of content, from the document at from and
+// now part of to. Links to a merged document become #anchor links: to the
+// heading they name or to the document's first heading. Other relative
+// links are rebased.
+func mergeLinks(content []byte, from, to string, anchors, first map[string]string) []byte {
+	var edits []edit
+	for _, link := range mdast.Links(parse(content), content) {
+		spans, ok := mdast.LinkSpansOf(content, link.Node)
+		if !ok {
+			continue
+		}
+		u, err := url.Parse(link.URL)
+		if err != nil {
+			continue
+		}
+		target := from
+		if !strings.HasPrefix(link.URL, "#") {
+			if target, ok = files.LocalTarget(from, link.URL); !ok {
+				continue
+			}
+		}
+
+		id, ok := anchors[target+"#"+u.Fragment]
+		if u.Fragment == "" {
+			id, ok = first[target]
+		}
+		destination := link.URL
+		switch {
+		case ok:
+			destination = "#" + id
+		case target == from:
+			continue
+		case filepath.Dir(from) != filepath.Dir(to):
+			if destination, ok = relativeDestination(link.URL, filepath.Dir(to), target); !ok {
+				continue
+			}
+		}
+		if destination != link.URL {
+			edits = append(edits, edit{span: spans.Destination, newText: destination})
+		}
+	}
+	return applyEdits(content, edits)
+}
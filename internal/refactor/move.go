@@ -0,0 +1,136 @@
+package refactor
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// Move plans moving the file oldPath to newPath. Relative links and images
+// in the markdown files under root that point at oldPath are changed to
+// point at newPath, and the relative links of a moved markdown file are
+// changed so they keep pointing at the same targets. When newPath is an
+// existing directory the file keeps its name. Link reference definitions
+// are changed like inline links.
+func Move(root, oldPath, newPath string) (*Plan, error) {
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory; only files can be moved", oldPath)
+	}
+	if info, err := os.Stat(newPath); err == nil {
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s already exists", newPath)
+		}
+		newPath = filepath.Join(newPath, filepath.Base(oldPath))
+		if _, err := os.Stat(newPath); err == nil {
+			return nil, fmt.Errorf("%s already exists", newPath)
+		}
+	}
+
+	absOld, err := filepath.Abs(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	absNew, err := filepath.Abs(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := files.Markdown([]string{root})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	for _, doc := range docs {
+		absDoc, err := filepath.Abs(doc)
+		if err != nil {
+			return nil, err
+		}
+		if absDoc == absOld {
+			continue
+		}
+		source, err := os.ReadFile(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", doc, err)
+		}
+		after := relink(source, absDoc, absDoc, func(target string) (string, bool) {
+			return absNew, target == absOld
+		})
+		plan.add(Change{Path: doc, Before: source, After: after})
+	}
+
+	source, err := os.ReadFile(oldPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", oldPath, err)
+	}
+	after := source
+	if files.IsMarkdown(oldPath) {
+		after = relink(source, absOld, absNew, func(target string) (string, bool) {
+			if target == absOld {
+				return absNew, true
+			}
+			return target, true
+		})
+	}
+	plan.Changes = append(plan.Changes, Change{OldPath: oldPath, Path: newPath, Before: source, After: after})
+	return plan, nil
+}
+
+// relink rewrites the relative destinations of the links, images and link
+// reference definitions of source, a document that was at from and will be
+// at to. retarget maps the absolute path a destination resolves to onto its
+// new absolute path, or reports false to leave the destination alone.
+func relink(source []byte, from, to string, retarget func(target string) (string, bool)) []byte {
+	var edits []edit
+	for _, link := range mdast.Links(parse(source), source) {
+		spans, ok := mdast.LinkSpansOf(source, link.Node)
+		if !ok {
+			continue
+		}
+		target, ok := files.LocalTarget(from, link.URL)
+		if !ok {
+			continue
+		}
+		newTarget, ok := retarget(target)
+		if !ok || (newTarget == target && filepath.Dir(from) == filepath.Dir(to)) {
+			continue
+		}
+		destination, ok := relativeDestination(link.URL, filepath.Dir(to), newTarget)
+		if ok && destination != link.URL {
+			edits = append(edits, edit{span: spans.Destination, newText: destination})
+		}
+	}
+	return applyEdits(source, edits)
+}
+
+// relativeDestination returns original with its path replaced by the path
+// from dir to target, keeping any query, fragment and "./" prefix.
+func relativeDestination(original, dir, target string) (string, bool) {
+	u, err := url.Parse(original)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, target)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if strings.HasSuffix(u.Path, "/") && !strings.HasSuffix(rel, "/") {
+		rel += "/"
+	}
+	if strings.HasPrefix(u.Path, "./") && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	u.Path = rel
+	u.RawPath = ""
+	return u.String(), true
+}
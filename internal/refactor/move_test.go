@@ -0,0 +1,94 @@
+package refactor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gkwa/bravewaldo/internal/testutil"
+)
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMove(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"README.md":         "See [the guide](docs/guide.md#install), [again](./docs/guide.md) and `[code](docs/guide.md)`.\n",
+		"docs/other.md":     "Back to [guide](guide.md \"title\") or [readme](../README.md).\n",
+		"docs/refs.md":      "The [guide][g] and [its install][install].\n\n[g]: ./guide.md\n[install]:\n  <guide.md#install> \"Install\"\n",
+		"docs/guide.md":     "# Guide\n\n[Other](other.md), [self](guide.md#guide), [top](#guide), ![logo](img/logo.png) and [web](https://go.dev).\n\n[Refs][r]\n\n[r]: refs.md\n",
+		"docs/img/logo.png": "",
+	})
+
+	plan, err := Move(dir, filepath.Join(dir, "docs", "guide.md"), filepath.Join(dir, "manual", "guide.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var diff bytes.Buffer
+	if err := plan.Diff(&diff); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff.String(), "+See [the guide](manual/guide.md#install)") {
+		t.Errorf("diff does not show the README change:\n%s", diff.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs", "guide.md")); err != nil {
+		t.Fatal("planning moved the file")
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"README.md":       "See [the guide](manual/guide.md#install), [again](./manual/guide.md) and `[code](docs/guide.md)`.\n",
+		"docs/other.md":   "Back to [guide](../manual/guide.md \"title\") or [readme](../README.md).\n",
+		"docs/refs.md":    "The [guide][g] and [its install][install].\n\n[g]: ../manual/guide.md\n[install]:\n  <../manual/guide.md#install> \"Install\"\n",
+		"manual/guide.md": "# Guide\n\n[Other](../docs/other.md), [self](guide.md#guide), [top](#guide), ![logo](../docs/img/logo.png) and [web](https://go.dev).\n\n[Refs][r]\n\n[r]: ../docs/refs.md\n",
+	}
+	for name, content := range want {
+		if diff := cmp.Diff(content, readFile(t, dir, name)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs", "guide.md")); !os.IsNotExist(err) {
+		t.Errorf("old file still exists: %v", err)
+	}
+}
+
+func TestMoveIntoDirectory(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"a.md":     "[b](b.md)\n",
+		"b.md":     "# B\n",
+		"sub/c.md": "[b](../b.md)\n",
+	})
+
+	plan, err := Move(dir, filepath.Join(dir, "b.md"), filepath.Join(dir, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, dir, "a.md"); got != "[b](sub/b.md)\n" {
+		t.Errorf("a.md = %q", got)
+	}
+	if got := readFile(t, dir, "sub/c.md"); got != "[b](b.md)\n" {
+		t.Errorf("sub/c.md = %q", got)
+	}
+
+	if _, err := Move(dir, filepath.Join(dir, "a.md"), filepath.Join(dir, "sub", "c.md")); err == nil {
+		t.Error("moving onto an existing file succeeded")
+	}
+}
@@ -0,0 +1,168 @@
+// Package refactor plans and applies changes that span a set of markdown
+// documents, such as moving a file and updating the links to it. Edits
+// replace only the bytes of the destinations or text they change, located
+// through goldmark AST positions.
+package refactor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// Change is the new content of one file. OldPath differs from Path when
+// the file is moved, and Before is nil when the file is created.
+type Change struct {
+	OldPath string
+	Path    string
+	Before  []byte
+	After   []byte
+}
+
+// Plan is a set of changes that have not been applied yet.
+type Plan struct {
+	Changes []Change
+}
+
+// edit replaces the bytes of span.
+type edit struct {
+	span    mdast.Span
+	newText string
+}
+
+func applyEdits(source []byte, edits []edit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].span.Start < edits[j].span.Start })
+	var out []byte
+	last := 0
+	for _, e := range edits {
+		if e.span.Start < last {
+			continue
+		}
+		out = append(out, source[last:e.span.Start]...)
+		out = append(out, e.newText...)
+		last = e.span.Stop
+	}
+	return append(out, source[last:]...)
+}
+
+func parse(source []byte) ast.Node {
+	return core.NewGoldmark().Parser().Parse(text.NewReader(source))
+}
+
+// add records a change unless it leaves the file as it was. After is
+// written in the style of Before: with its line endings, byte order mark
+// and final newline.
+func (p *Plan) add(c Change) {
+	if c.Before != nil {
+		c.After = eol.Match(c.Before, c.After, "")
+	}
+	p.record(c)
+}
+
+// addStyled records a change with After written in style, that of the
+// documents it is made from, unless it leaves the file as it was.
+func (p *Plan) addStyled(c Change, style eol.Style) {
+	c.After = style.Apply(c.After)
+	p.record(c)
+}
+
+func (p *Plan) record(c Change) {
+	if c.OldPath == "" {
+		c.OldPath = c.Path
+	}
+	if c.OldPath == c.Path && string(c.Before) == string(c.After) {
+		return
+	}
+	p.Changes = append(p.Changes, c)
+}
+
+// SetLineEnding writes the new content of every change with lineEnding
+// instead of the line endings of the documents, unless it is empty.
+func (p *Plan) SetLineEnding(lineEnding string) {
+	for i := range p.Changes {
+		p.Changes[i].After = eol.Convert(p.Changes[i].After, lineEnding)
+	}
+}
+
+// Diff writes a unified diff of the plan to w.
+func (p *Plan) Diff(w io.Writer) error {
+	for _, c := range p.Changes {
+		from := diffName("a/", c.OldPath)
+		if c.Before == nil {
+			from = "/dev/null"
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        lines(c.Before),
+			B:        lines(c.After),
+			FromFile: from,
+			ToFile:   diffName("b/", c.Path),
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			// A move without content changes.
+			diff = fmt.Sprintf("rename from %s\nrename to %s\n", c.OldPath, c.Path)
+		}
+		if _, err := io.WriteString(w, diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lines splits b after each newline. Unlike difflib.SplitLines it does not
+// invent an empty last line.
+func lines(b []byte) []string {
+	result := strings.SplitAfter(string(b), "\n")
+	if result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	}
+	return result
+}
+
+// diffName prefixes relative paths the way git diff does.
+func diffName(prefix, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	return prefix + filepath.ToSlash(path)
+}
+
+// Apply writes the changes, moving files whose path changed.
+func (p *Plan) Apply() error {
+	for _, c := range p.Changes {
+		if c.OldPath != c.Path {
+			if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", c.Path, err)
+			}
+			if err := os.Rename(c.OldPath, c.Path); err != nil {
+				return fmt.Errorf("failed to move %s: %w", c.OldPath, err)
+			}
+		}
+		if string(c.Before) == string(c.After) {
+			continue
+		}
+		if c.Before == nil {
+			if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", c.Path, err)
+			}
+		}
+		if err := os.WriteFile(c.Path, c.After, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", c.Path, err)
+		}
+	}
+	return nil
+}
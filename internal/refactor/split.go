@@ -0,0 +1,291 @@
+package refactor
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/linkcheck"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+var frontMatterRegex = regexp.MustCompile(`(?s)\A---\r?\n.*?\r?\n---\r?\n`)
+
+// anchor is the file a heading ends up in and its ID there.
+type anchor struct {
+	path string
+	id   string
+}
+
+type section struct {
+	heading *ast.Heading
+	span    mdast.Span
+	path    string
+}
+
+// Split plans breaking the markdown file at path into one file per
+// top-level heading of the given level, written to dir as <heading ID>.md.
+// When dir is empty it is the path without its extension. A section runs
+// up to the next heading of the same or a higher level, and its headings
+// are promoted so that it starts with a level 1 heading. The file at path
+// becomes the index: everything outside the sections stays, and each run
+// of sections is replaced by a list of links to their files. The front
+// matter is copied into every file, #anchor links are rewritten to point
+// into the file that now holds the heading, and other relative links are
+// rebased onto the new files.
+func Split(path string, level int, dir string) (*Plan, error) {
+	if level < 1 || level > 6 {
+		return nil, fmt.Errorf("heading level must be between 1 and 6")
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	// The new files are written like the original.
+	source, style := eol.Normalize(raw)
+	if dir == "" {
+		dir = strings.TrimSuffix(path, filepath.Ext(path))
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var frontMatter []byte
+	if m := frontMatterRegex.FindIndex(source); m != nil {
+		frontMatter = source[:m[1]]
+	}
+
+	doc := parse(source)
+	var sections []section
+	open := false
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		heading, ok := n.(*ast.Heading)
+		if !ok || heading.Level > level {
+			continue
+		}
+		start := lineStart(source, heading.Pos())
+		if open {
+			sections[len(sections)-1].span.Stop = start
+		}
+		open = heading.Level == level
+		if open {
+			sections = append(sections, section{
+				heading: heading,
+				span:    mdast.Span{Start: start, Stop: len(source)},
+				path:    filepath.Join(absDir, headingID(heading)+".md"),
+			})
+		}
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("%s has no level %d headings", path, level)
+	}
+
+	// The file each heading goes to, in document order.
+	var origins []anchor
+	for _, h := range headingNodes(doc) {
+		target := absPath
+		for _, s := range sections {
+			if s.span.Contains(h.Pos()) {
+				target = s.path
+			}
+		}
+		origins = append(origins, anchor{path: target, id: headingID(h)})
+	}
+
+	contents := map[string][]byte{}
+	var index bytes.Buffer
+	last := 0
+	for i, s := range sections {
+		index.Write(source[last:s.span.Start])
+		rel, err := filepath.Rel(filepath.Dir(absPath), s.path)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&index, "- [%s](%s)\n", linkTextEscaper.Replace(mdast.PlainText(source, s.heading)), filepath.ToSlash(rel))
+		last = s.span.Stop
+		endOfRun := i+1 == len(sections) || sections[i+1].span.Start != last
+		if endOfRun && last < len(source) {
+			index.WriteByte('\n')
+		}
+
+		content := append(bytes.TrimRight(source[s.span.Start:s.span.Stop], "\n"), '\n')
+		contents[s.path] = append(append([]byte{}, frontMatter...), shiftHeadings(content, 1-level)...)
+	}
+	index.Write(source[last:])
+	contents[absPath] = index.Bytes()
+
+	// IDs can change once headings with the same text are in different
+	// files and lose their numeric suffixes.
+	anchors := map[string]anchor{}
+	for p, content := range contents {
+		ids := linkcheck.HeadingIDs(parse(content))
+		i := 0
+		for _, origin := range origins {
+			if origin.path != p {
+				continue
+			}
+			if i < len(ids) {
+				anchors[origin.id] = anchor{path: p, id: ids[i]}
+			}
+			i++
+		}
+	}
+
+	plan := &Plan{}
+	plan.addStyled(Change{Path: path, Before: raw, After: splitLinks(contents[absPath], absPath, absPath, anchors)}, style)
+	for _, s := range sections {
+		if _, err := os.Stat(s.path); err == nil {
+			return nil, fmt.Errorf("%s already exists", s.path)
+		}
+		plan.addStyled(Change{Path: relativePath(path, absPath, s.path), After: splitLinks(contents[s.path], absPath, s.path, anchors)}, style)
+	}
+	return plan, nil
+}
+
+// splitLinks rewrites the links of content, written for the document at
+// from and now at to. Links to a heading of from go to the file in
+// anchors that holds it and other relative links are rebased.
+func splitLinks(content []byte, from, to string, anchors map[string]anchor) []byte {
+	var edits []edit
+	for _, link := range mdast.Links(parse(content), content) {
+		spans, ok := mdast.LinkSpansOf(content, link.Node)
+		if !ok {
+			continue
+		}
+		u, err := url.Parse(link.URL)
+		if err != nil {
+			continue
+		}
+		target := from
+		if !strings.HasPrefix(link.URL, "#") {
+			if target, ok = files.LocalTarget(from, link.URL); !ok {
+				continue
+			}
+		}
+
+		destination := link.URL
+		if a, ok := anchors[u.Fragment]; ok && target == from && u.Fragment != "" {
+			destination = "#" + a.id
+			if a.path != to {
+				rel, err := filepath.Rel(filepath.Dir(to), a.path)
+				if err != nil {
+					continue
+				}
+				destination = filepath.ToSlash(rel) + destination
+			}
+		} else if filepath.Dir(from) != filepath.Dir(to) || (target == from && from != to) {
+			if destination, ok = relativeDestination(link.URL, filepath.Dir(to), target); !ok {
+				continue
+			}
+		}
+		if destination != link.URL {
+			edits = append(edits, edit{span: spans.Destination, newText: destination})
+		}
+	}
+	return applyEdits(content, edits)
+}
+
+// shiftHeadings changes the level of every heading in source by delta,
+// keeping levels between 1 and 6. Setext headings that end up below level
+// 2 are rewritten as ATX headings.
+func shiftHeadings(source []byte, delta int) []byte {
+	if delta == 0 {
+		return source
+	}
+	var edits []edit
+	for _, h := range headingNodes(parse(source)) {
+		level := min(max(h.Level+delta, 1), 6)
+		if level == h.Level {
+			continue
+		}
+		lines := h.Lines()
+		start := lineStart(source, h.Pos())
+		contentStart := lineEnd(source, h.Pos())
+		if lines.Len() > 0 {
+			contentStart = lines.At(0).Start
+		}
+
+		if i := bytes.IndexByte(source[start:contentStart], '#'); i >= 0 {
+			i += start
+			j := i
+			for j < len(source) && source[j] == '#' {
+				j++
+			}
+			edits = append(edits, edit{span: mdast.Span{Start: i, Stop: j}, newText: strings.Repeat("#", level)})
+			continue
+		}
+
+		// A setext heading: its underline is the line after its text.
+		if lines.Len() == 0 {
+			continue
+		}
+		underline := lineEnd(source, lines.At(lines.Len()-1).Start) + 1
+		if underline > len(source) {
+			continue
+		}
+		end := lineEnd(source, underline)
+		if level <= 2 {
+			marker := bytes.TrimSpace(source[underline:end])
+			i := underline + bytes.Index(source[underline:end], marker)
+			char := "="
+			if level == 2 {
+				char = "-"
+			}
+			edits = append(edits, edit{span: mdast.Span{Start: i, Stop: i + len(marker)}, newText: strings.Repeat(char, len(marker))})
+			continue
+		}
+		var text []string
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			text = append(text, string(bytes.TrimSpace(line.Value(source))))
+		}
+		edits = append(edits, edit{
+			span:    mdast.Span{Start: contentStart, Stop: end},
+			newText: strings.Repeat("#", level) + " " + strings.Join(text, " "),
+		})
+	}
+	return applyEdits(source, edits)
+}
+
+func headingID(h *ast.Heading) string {
+	if id, ok := h.AttributeString("id"); ok {
+		if b, ok := id.([]byte); ok {
+			return string(b)
+		}
+	}
+	return ""
+}
+
+// relativePath returns target, an absolute path, relative to the directory
+// of path the way path itself is written.
+func relativePath(path, absPath, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(absPath), target)
+	if err != nil {
+		return target
+	}
+	return filepath.Join(filepath.Dir(path), rel)
+}
+
+func lineStart(source []byte, pos int) int {
+	return bytes.LastIndexByte(source[:pos], '\n') + 1
+}
+
+func lineEnd(source []byte, pos int) int {
+	if i := bytes.IndexByte(source[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(source)
+}
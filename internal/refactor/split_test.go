@@ -0,0 +1,155 @@
+package refactor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gkwa/bravewaldo/internal/testutil"
+)
+
+func TestSplit(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"guide.md": "---\ntitle: Guide\n---\n# Guide\n\nIntro, see [usage](#usage) and [setup](other.md).\n\n" +
+			"## Install\n\nRun it. Then read [usage](guide.md#usage).\n\n### Example\n\nOne.\n\n" +
+			"Usage\n-----\n\nBack to [install](#install) or [the top](#guide).\n\n### Example\n\nTwo, see [this](#example-1).\n\n" +
+			"# Appendix\n\nMore.\n",
+	})
+
+	plan, err := Split(filepath.Join(dir, "guide.md"), 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"guide.md": "---\ntitle: Guide\n---\n# Guide\n\nIntro, see [usage](guide/usage.md#usage) and [setup](other.md).\n\n" +
+			"- [Install](guide/install.md)\n- [Usage](guide/usage.md)\n\n" +
+			"# Appendix\n\nMore.\n",
+		"guide/install.md": "---\ntitle: Guide\n---\n# Install\n\nRun it. Then read [usage](usage.md#usage).\n\n## Example\n\nOne.\n",
+		"guide/usage.md":   "---\ntitle: Guide\n---\nUsage\n=====\n\nBack to [install](install.md#install) or [the top](../guide.md#guide).\n\n## Example\n\nTwo, see [this](#example).\n",
+	}
+	for name, content := range want {
+		if diff := cmp.Diff(content, readFile(t, dir, name)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+
+	testutil.WriteFiles(t, dir, map[string]string{"again.md": "## Install\n"})
+	if _, err := Split(filepath.Join(dir, "again.md"), 2, filepath.Join(dir, "guide")); err == nil {
+		t.Error("Split() overwrote an existing file")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"parts/one.md":    "---\ntitle: One\n---\n# Setup\n\nSee [two](two.md), [its example](two.md#example) and [here](#setup).\n\n## Example\n\n![logo](img/logo.png)\n",
+		"parts/two.md":    "---\ntitle: Two\n---\nUsage\n=====\n\n## Example\n\nBack to [one](./one.md#example).\n",
+		"parts/order.txt": "# chapters\ntwo.md\n\none.md\n",
+	})
+
+	order, err := ReadOrder(filepath.Join(dir, "parts", "order.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Merge(order, filepath.Join(dir, "book.md"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\ntitle: Two\n---\nUsage\n-----\n\n### Example\n\nBack to [one](#example-1).\n\n" +
+		"## Setup\n\nSee [two](#usage), [its example](#example) and [here](#setup).\n\n### Example\n\n![logo](parts/img/logo.png)\n"
+	if diff := cmp.Diff(want, string(plan.Changes[0].After)); diff != "" {
+		t.Errorf("Merge() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSplitDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"guide.md": "# Guide\n\nSee [setup][s].\n\n[s]: other.md\n\n" +
+			"## Install\n\nThen read [usage][u] and see ![logo][l].\n\n[u]: #usage\n[l]: img/logo.png \"Logo\"\n\n" +
+			"## Usage\n\nBack to [install][i].\n\n[i]: <#install>\n",
+	})
+
+	plan, err := Split(filepath.Join(dir, "guide.md"), 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"guide.md":         "# Guide\n\nSee [setup][s].\n\n[s]: other.md\n\n- [Install](guide/install.md)\n- [Usage](guide/usage.md)\n",
+		"guide/install.md": "# Install\n\nThen read [usage][u] and see ![logo][l].\n\n[u]: usage.md#usage\n[l]: ../img/logo.png \"Logo\"\n",
+		"guide/usage.md":   "# Usage\n\nBack to [install][i].\n\n[i]: <install.md#install>\n",
+	}
+	for name, content := range want {
+		if diff := cmp.Diff(content, readFile(t, dir, name)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestMergeDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFiles(t, dir, map[string]string{
+		"parts/one.md": "# Setup\n\nSee [its example][e] and ![logo][l].\n\n[e]: two.md#example\n[l]: img/logo.png\n",
+		"parts/two.md": "# Usage\n\n## Example\n\nBack to [one][o].\n\n[o]: <./one.md>\n",
+	})
+
+	plan, err := Merge([]string{filepath.Join(dir, "parts", "one.md"), filepath.Join(dir, "parts", "two.md")}, filepath.Join(dir, "book.md"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Setup\n\nSee [its example][e] and ![logo][l].\n\n[e]: #example\n[l]: parts/img/logo.png\n\n" +
+		"# Usage\n\n## Example\n\nBack to [one][o].\n\n[o]: <#setup>\n"
+	if diff := cmp.Diff(want, string(plan.Changes[0].After)); diff != "" {
+		t.Errorf("Merge() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSplitMergeRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	original := "# Guide\n\nSee [usage](#usage).\n\n## Install\n\nRun it.\n\n## Usage\n\nBack to [install](#install).\n\n# Appendix\n\nMore.\n"
+	testutil.WriteFiles(t, dir, map[string]string{"guide.md": original})
+
+	plan, err := Split(filepath.Join(dir, "guide.md"), 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	plan, err = MergeIndex(filepath.Join(dir, "guide.md"), filepath.Join(dir, "merged.md"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(original, string(plan.Changes[0].After)); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestShiftHeadings(t *testing.T) {
+	tests := []struct {
+		source string
+		delta  int
+		want   string
+	}{
+		{"# A\n\n## B\n", 1, "## A\n\n### B\n"},
+		{"### A\n> ## B\n", -1, "## A\n> # B\n"},
+		{"A\n===\n\nB\n---\n", 1, "A\n---\n\n### B\n"},
+		{"###### A\n", 1, "###### A\n"},
+		{"Two\nlines\n===\n", 2, "### Two lines\n"},
+	}
+	for _, tt := range tests {
+		if got := string(shiftHeadings([]byte(tt.source), tt.delta)); got != tt.want {
+			t.Errorf("shiftHeadings(%q, %d) = %q, want %q", tt.source, tt.delta, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	githubData     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func githubCommand(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "notice"
+}
+
+// writeGitHub writes GitHub Actions workflow commands, which the runner
+// turns into annotations on the changed files.
+func writeGitHub(w io.Writer, r Report) error {
+	for _, f := range r.Findings {
+		props := []string{
+			"file=" + githubProperty.Replace(f.Path),
+			fmt.Sprintf("line=%d", f.Line),
+			fmt.Sprintf("col=%d", f.Column),
+		}
+		if f.EndLine > 0 {
+			props = append(props, fmt.Sprintf("endLine=%d", f.EndLine))
+			// GitHub only honours endColumn on single line annotations.
+			if f.EndLine == f.Line {
+				props = append(props, fmt.Sprintf("endColumn=%d", f.EndColumn))
+			}
+		}
+		props = append(props, "title="+githubProperty.Replace(f.Rule))
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", githubCommand(f.Severity), strings.Join(props, ","), githubData.Replace(f.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
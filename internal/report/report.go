@@ -0,0 +1,117 @@
+// Package report writes findings in the formats CI tools ingest: plain
+// text, SARIF 2.1.0, JUnit XML, GitHub Actions annotations and checkstyle
+// XML.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	FormatText       = "text"
+	FormatSARIF      = "sarif"
+	FormatJUnit      = "junit"
+	FormatGitHub     = "github"
+	FormatCheckstyle = "checkstyle"
+)
+
+// Formats lists the supported formats, text first.
+var Formats = []string{FormatText, FormatSARIF, FormatJUnit, FormatGitHub, FormatCheckstyle}
+
+// Severity is one of "error", "warning" or "info".
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Finding is a problem at a location. Lines and columns are 1-based and
+// columns count runes. EndLine and EndColumn point just past the problem
+// and are zero when unknown.
+type Finding struct {
+	Rule      string
+	Severity  Severity
+	Path      string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	Message   string
+}
+
+type Rule struct {
+	ID          string
+	Description string
+}
+
+// Report is the result of one run of a checking command.
+type Report struct {
+	// Tool names the command, for example "lint".
+	Tool  string
+	Rules []Rule
+	// Paths lists every file that was checked, including files without
+	// findings, so that JUnit can report them as passing.
+	Paths    []string
+	Findings []Finding
+}
+
+// ValidateFormat returns an error naming the supported formats when
+// format is not one of them.
+func ValidateFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// Write writes r to w in format.
+func Write(w io.Writer, format string, r Report) error {
+	switch format {
+	case FormatText:
+		return writeText(w, r)
+	case FormatSARIF:
+		return writeSARIF(w, r)
+	case FormatJUnit:
+		return writeJUnit(w, r)
+	case FormatGitHub:
+		return writeGitHub(w, r)
+	case FormatCheckstyle:
+		return writeCheckstyle(w, r)
+	}
+	return ValidateFormat(format)
+}
+
+func writeText(w io.Writer, r Report) error {
+	for _, f := range r.Findings {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s %s %s\n", f.Path, f.Line, f.Column, f.Severity, f.Rule, f.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byPath groups findings by path, covering every path in r.Paths plus any
+// path that only appears in a finding, in sorted order.
+func byPath(r Report) ([]string, map[string][]Finding) {
+	groups := map[string][]Finding{}
+	for _, path := range r.Paths {
+		groups[path] = nil
+	}
+	for _, f := range r.Findings {
+		groups[f.Path] = append(groups[f.Path], f)
+	}
+
+	paths := make([]string, 0, len(groups))
+	for path := range groups {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, groups
+}
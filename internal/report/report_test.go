@@ -0,0 +1,93 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+var testReport = Report{
+	Tool:  "lint",
+	Rules: []Rule{{ID: "bare-url", Description: "no bare URLs"}},
+	Paths: []string{"a.md", "b.md"},
+	Findings: []Finding{{
+		Rule:      "bare-url",
+		Severity:  SeverityWarning,
+		Path:      "a.md",
+		Line:      3,
+		Column:    5,
+		EndLine:   3,
+		EndColumn: 24,
+		Message:   "bare URL https://go.dev, 100%",
+	}},
+}
+
+func write(t *testing.T, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Write(&buf, format, testReport); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestText(t *testing.T) {
+	want := "a.md:3:5: warning bare-url bare URL https://go.dev, 100%\n"
+	if got := write(t, FormatText); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	want := "::warning file=a.md,line=3,col=5,endLine=3,endColumn=24,title=bare-url::bare URL https://go.dev, 100%25\n"
+	if got := write(t, FormatGitHub); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	var log sarifLog
+	if err := json.Unmarshal([]byte(write(t, FormatSARIF)), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("unexpected log %+v", log)
+	}
+	result := log.Runs[0].Results[0]
+	region := result.Locations[0].PhysicalLocation.Region
+	if result.Level != "warning" || result.RuleIndex == nil || *result.RuleIndex != 0 || region != (sarifRegion{3, 5, 3, 24}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	var suites junitTestSuites
+	if err := xml.Unmarshal([]byte(write(t, FormatJUnit)), &suites); err != nil {
+		t.Fatal(err)
+	}
+	if suites.Tests != 2 || suites.Failures != 1 || len(suites.Suites) != 2 {
+		t.Fatalf("unexpected suites %+v", suites)
+	}
+	if passing := suites.Suites[1]; passing.Name != "b.md" || passing.Cases[0].Failure != nil {
+		t.Errorf("b.md should pass, got %+v", passing)
+	}
+}
+
+func TestCheckstyle(t *testing.T) {
+	var result checkstyleResult
+	if err := xml.Unmarshal([]byte(write(t, FormatCheckstyle)), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 2 || len(result.Files[0].Errors) != 1 || result.Files[0].Errors[0].Source != "bravewaldo.bare-url" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "yaml", testReport)
+	if err == nil || !strings.Contains(err.Error(), "sarif") {
+		t.Errorf("got %v, want error listing formats", err)
+	}
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes one test suite per file. Each finding is a failed test
+// case and a file without findings is a single passing one.
+func writeJUnit(w io.Writer, r Report) error {
+	suites := junitTestSuites{Name: "bravewaldo " + r.Tool}
+	paths, groups := byPath(r)
+	for _, path := range paths {
+		suite := junitTestSuite{Name: path}
+		for _, f := range groups[path] {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      fmt.Sprintf("%s:%d:%d %s", path, f.Line, f.Column, f.Rule),
+				ClassName: path,
+				Failure: &junitFailure{
+					Message: f.Message,
+					Type:    string(f.Severity),
+					Text:    fmt.Sprintf("%s:%d:%d: %s %s", path, f.Line, f.Column, f.Rule, f.Message),
+				},
+			})
+			suite.Failures++
+		}
+		if len(suite.Cases) == 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{Name: path, ClassName: path})
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+	return writeXML(w, suites)
+}
+
+type checkstyleResult struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func writeCheckstyle(w io.Writer, r Report) error {
+	result := checkstyleResult{Version: "4.3"}
+	paths, groups := byPath(r)
+	for _, path := range paths {
+		file := checkstyleFile{Name: path}
+		for _, f := range groups[path] {
+			file.Errors = append(file.Errors, checkstyleError{
+				Line:     f.Line,
+				Column:   f.Column,
+				Severity: string(f.Severity),
+				Message:  f.Message,
+				Source:   "bravewaldo." + f.Rule,
+			})
+		}
+		result.Files = append(result.Files, file)
+	}
+	return writeXML(w, result)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
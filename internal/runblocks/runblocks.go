@@ -0,0 +1,219 @@
+// Package runblocks executes the fenced code blocks of a markdown document
+// that are marked with the exec flag, as in ```sh exec, and records their
+// output in a block flagged output right after each of them.
+package runblocks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/codeblock"
+	"github.com/gkwa/bravewaldo/internal/eol"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+const (
+	// ExecFlag marks a block to run.
+	ExecFlag = "exec"
+	// OutputFlag marks the block holding the output of the block before
+	// it.
+	OutputFlag = "output"
+	// DefaultOutputInfo is the info string of new output blocks.
+	DefaultOutputInfo = "text " + OutputFlag
+)
+
+type Options struct {
+	// Shell is the command and arguments the block content is appended to,
+	// such as ["sh", "-c"].
+	Shell []string
+	// Dir is the working directory. When empty blocks run in the
+	// directory of their document. A dir attribute on the block is
+	// relative to it.
+	Dir string
+	// Env is added to the environment as KEY=VALUE pairs.
+	Env []string
+	// Timeout limits each block unless it has a timeout attribute, such as
+	// timeout=1m. Zero means no limit.
+	Timeout time.Duration
+	// LineEnding, when set, replaces the line endings of the document in
+	// After. Otherwise they are kept.
+	LineEnding string
+}
+
+// Result is the outcome of running the blocks of one document. Stale
+// holds the lines of the exec blocks whose recorded output differed.
+type Result struct {
+	Path   string
+	Before []byte
+	After  []byte
+	Stale  []int
+}
+
+// Run executes the exec blocks of the document at path in order and
+// returns the document with their output blocks refreshed. Nothing is
+// written.
+func Run(ctx context.Context, path string, opts Options) (*Result, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	source, style := eol.Normalize(raw)
+	doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+	blocks := codeblock.Blocks(doc, source)
+	outputs := map[ast.Node]codeblock.Block{}
+	for _, b := range blocks {
+		if b.Info.Has(OutputFlag) {
+			outputs[b.Node] = b
+		}
+	}
+
+	result := &Result{Path: path, Before: raw}
+	type edit struct {
+		span mdast.Span
+		text string
+	}
+	var edits []edit
+	for _, block := range blocks {
+		if !block.Info.Has(ExecFlag) {
+			continue
+		}
+		output, err := execute(ctx, path, block, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, block.Line-1, err)
+		}
+
+		prefix := mdast.LinePrefix(source, block.Span.Start)
+		if recorded, ok := outputs[block.Node.NextSibling()]; ok {
+			if bytes.Equal(recorded.Content, output) {
+				continue
+			}
+			info := infoString(source, recorded)
+			edits = append(edits, edit{span: recorded.Span, text: fenced(info, output, prefix)})
+		} else {
+			separator := strings.TrimRight(prefix, " ")
+			edits = append(edits, edit{
+				span: mdast.Span{Start: block.Span.Stop, Stop: block.Span.Stop},
+				text: "\n" + separator + "\n" + prefix + fenced(DefaultOutputInfo, output, prefix),
+			})
+		}
+		result.Stale = append(result.Stale, block.Line-1)
+	}
+
+	var after []byte
+	last := 0
+	for _, e := range edits {
+		after = append(after, source[last:e.span.Start]...)
+		after = append(after, e.text...)
+		last = e.span.Stop
+	}
+	result.After = style.WithLineEnding(opts.LineEnding).Apply(append(after, source[last:]...))
+	return result, nil
+}
+
+// execute runs a block and returns its combined stdout and stderr, ending
+// with the exit status when it is not zero.
+func execute(ctx context.Context, path string, block codeblock.Block, opts Options) ([]byte, error) {
+	timeout := opts.Timeout
+	if t, ok := block.Info.Attrs["timeout"]; ok {
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", t, err)
+		}
+		timeout = d
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	shell := opts.Shell
+	if len(shell) == 0 {
+		shell = []string{"sh", "-c"}
+	}
+	cmd := exec.CommandContext(ctx, shell[0], append(shell[1:], string(block.Content))...)
+	cmd.Dir = opts.Dir
+	if cmd.Dir == "" {
+		cmd.Dir = filepath.Dir(path)
+	}
+	if dir := block.Info.Attrs["dir"]; dir != "" {
+		cmd.Dir = filepath.Join(cmd.Dir, dir)
+	}
+	cmd.Env = append(os.Environ(), opts.Env...)
+	// Children of the shell may keep the output pipe open after it is
+	// killed.
+	cmd.WaitDelay = time.Second
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+			out.WriteByte('\n')
+		}
+		fmt.Fprintf(&out, "[exit status %d]\n", exitErr.ExitCode())
+	case err != nil:
+		return nil, err
+	}
+
+	if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// infoString returns the info string of an output block as written.
+func infoString(source []byte, block codeblock.Block) string {
+	return string(block.Node.Info.Segment.Value(source))
+}
+
+// fenced returns a fenced block holding content, starting with the
+// opening fence and ending without a newline after the closing fence.
+// Lines after the first get prefix. The fence is longer than any run of
+// backticks in content.
+func fenced(info string, content []byte, prefix string) string {
+	longest := 0
+	run := 0
+	for _, c := range content {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	var b strings.Builder
+	b.WriteString(fence + info + "\n")
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if line == "" {
+			continue
+		}
+		if line == "\n" {
+			b.WriteString(strings.TrimRight(prefix, " ") + line)
+		} else {
+			b.WriteString(prefix + line)
+		}
+	}
+	b.WriteString(prefix + fence)
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package runblocks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "doc.md")
+	source := "# Demo\n\n" +
+		"```sh exec\necho $GREETING\necho oops >&2\n```\n\n" +
+		"> ```sh exec dir=sub\n> basename \"$PWD\"\n> ```\n\n" +
+		"```sh exec\necho '```'; exit 2\n```\n\n" +
+		"```console output\nstale\n```\n\n" +
+		"```sh\necho not run\n```\n"
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Env: []string{"GREETING=hi"}, Timeout: 10 * time.Second}
+	result, err := Run(context.Background(), path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Demo\n\n" +
+		"```sh exec\necho $GREETING\necho oops >&2\n```\n\n" +
+		"```text output\nhi\noops\n```\n\n" +
+		"> ```sh exec dir=sub\n> basename \"$PWD\"\n> ```\n>\n" +
+		"> ```text output\n> sub\n> ```\n\n" +
+		"```sh exec\necho '```'; exit 2\n```\n\n" +
+		"````console output\n```\n[exit status 2]\n````\n\n" +
+		"```sh\necho not run\n```\n"
+	if diff := cmp.Diff(want, string(result.After)); diff != "" {
+		t.Errorf("Run mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{3, 8, 12}, result.Stale); diff != "" {
+		t.Errorf("stale lines mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := os.WriteFile(path, result.After, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Run(context.Background(), path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Stale) != 0 || string(again.After) != want {
+		t.Errorf("second run is stale at %v:\n%s", again.Stale, again.After)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(path, []byte("```sh exec timeout=100ms\nsleep 5\n```\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(context.Background(), path, Options{}); err == nil {
+		t.Error("Run did not time out")
+	}
+}
@@ -0,0 +1,238 @@
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// cellEscaper keeps a value inside one cell.
+var cellEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+// FromRecords builds a table from records whose first record is the
+// header. Values are escaped for use in a cell, and columns whose values
+// are all numbers are right aligned.
+func FromRecords(records [][]string) (*Table, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header row")
+	}
+	t := &Table{}
+	for i, record := range records {
+		cells := make([]string, len(record))
+		for j, value := range record {
+			cells[j] = cellEscaper.Replace(strings.TrimSpace(value))
+		}
+		if i == 0 {
+			t.Header = cells
+		} else {
+			t.Rows = append(t.Rows, cells)
+		}
+	}
+
+	t.Align = make([]east.Alignment, t.columns())
+	for i := range t.Align {
+		numeric := false
+		for _, row := range t.Rows {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			if _, err := strconv.ParseFloat(strings.ReplaceAll(row[i], ",", ""), 64); err != nil {
+				numeric = false
+				break
+			}
+			numeric = true
+		}
+		if numeric {
+			t.Align[i] = east.AlignRight
+		}
+	}
+	return t, nil
+}
+
+// ReadCSV reads a table from CSV, or TSV when comma is a tab.
+func ReadCSV(r io.Reader, comma rune) (*Table, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	if comma == '\t' {
+		reader.LazyQuotes = true
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return FromRecords(records)
+}
+
+// ReadJSON reads a table from a JSON array of objects, whose keys in order
+// of first appearance become the columns, or from an array of arrays whose
+// first element is the header. Strings are used as they are, null is
+// empty and other values are written as compact JSON.
+func ReadJSON(r io.Reader) (*Table, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, fmt.Errorf("expected a JSON array")
+	}
+
+	var columns []string
+	index := map[string]int{}
+	var rows []map[string]string
+	var records [][]string
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		switch bytes.TrimSpace(raw)[0] {
+		case '{':
+			row, keys, err := readObject(raw)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				if _, ok := index[key]; !ok {
+					index[key] = len(columns)
+					columns = append(columns, key)
+				}
+			}
+			rows = append(rows, row)
+		case '[':
+			var values []json.RawMessage
+			if err := json.Unmarshal(raw, &values); err != nil {
+				return nil, err
+			}
+			record := make([]string, len(values))
+			for i, v := range values {
+				record[i] = jsonValue(v)
+			}
+			records = append(records, record)
+		default:
+			return nil, fmt.Errorf("expected objects or arrays, got %s", raw)
+		}
+	}
+	if rows != nil && records != nil {
+		return nil, fmt.Errorf("expected either objects or arrays, not both")
+	}
+
+	if rows != nil {
+		records = [][]string{columns}
+		for _, row := range rows {
+			record := make([]string, len(columns))
+			for i, column := range columns {
+				record[i] = row[column]
+			}
+			records = append(records, record)
+		}
+	}
+	return FromRecords(records)
+}
+
+// readObject returns the values of a JSON object and its keys in order.
+func readObject(raw json.RawMessage) (map[string]string, []string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	values := map[string]string{}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		values[key] = jsonValue(value)
+	}
+	return values, keys, nil
+}
+
+func jsonValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return string(raw)
+	}
+	return compact.String()
+}
+
+// value returns the text of a cell with escaped pipes unescaped.
+func value(cell string) string {
+	return strings.ReplaceAll(cell, `\|`, "|")
+}
+
+// Records returns the header and rows of the table, padded to the same
+// number of columns, with escaped pipes unescaped.
+func (t *Table) Records() [][]string {
+	n := t.columns()
+	var records [][]string
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		record := make([]string, n)
+		for i, cell := range row {
+			record[i] = value(cell)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// WriteCSV writes the table as CSV, or TSV when comma is a tab.
+func (t *Table) WriteCSV(w io.Writer, comma rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if err := writer.WriteAll(t.Records()); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteJSON writes the rows as an array of objects keyed by the header, in
+// column order.
+func (t *Table) WriteJSON(w io.Writer) error {
+	records := t.Records()
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i, record := range records[1:] {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for j, v := range record {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			key, _ := json.Marshal(records[0][j])
+			val, _ := json.Marshal(v)
+			b.Write(key)
+			b.WriteString(": ")
+			b.Write(val)
+		}
+		b.WriteString("}")
+	}
+	if len(records) > 1 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,119 @@
+package table
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	core "github.com/gkwa/bravewaldo/core1"
+)
+
+// Format lays out every table in source, changing only the table lines
+// after any blockquote or list prefix and leaving the rest of the document
+// byte for byte as it was.
+func Format(source []byte) []byte {
+	doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+	type edit struct {
+		start, stop int
+		text        string
+	}
+	var edits []edit
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		node, ok := n.(*east.Table)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		lines := FromNode(node, source).Lines()
+
+		// Row positions are just after the container prefix of their line;
+		// the delimiter row is the line after the header.
+		header := node.FirstChild()
+		starts := []int{contentStart(source, header.Pos())}
+		delimiter := lineEnd(source, header.Pos()) + 1
+		starts = append(starts, delimiterStart(source, delimiter))
+		for row := header.NextSibling(); row != nil; row = row.NextSibling() {
+			starts = append(starts, contentStart(source, row.Pos()))
+		}
+		for i, start := range starts {
+			edits = append(edits, edit{start: start, stop: lineEnd(source, start), text: lines[i]})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+
+	var out []byte
+	last := 0
+	for _, e := range edits {
+		out = append(out, source[last:e.start]...)
+		out = append(out, e.text...)
+		last = e.stop
+	}
+	return append(out, source[last:]...)
+}
+
+// contentStart skips the indentation at pos.
+func contentStart(source []byte, pos int) int {
+	for pos < len(source) && (source[pos] == ' ' || source[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
+
+// delimiterStart returns where the delimiter row starting its line at
+// lineStart begins after its container prefix.
+func delimiterStart(source []byte, lineStart int) int {
+	end := lineEnd(source, lineStart)
+	if i := bytes.IndexAny(source[lineStart:end], "|-:"); i >= 0 {
+		return lineStart + i
+	}
+	return end
+}
+
+// lineEnd returns the offset of the newline, or carriage return before
+// it, ending the line containing pos.
+func lineEnd(source []byte, pos int) int {
+	end := len(source)
+	if i := bytes.IndexByte(source[pos:], '\n'); i >= 0 {
+		end = pos + i
+	}
+	if end > pos && source[end-1] == '\r' {
+		end--
+	}
+	return end
+}
+
+// NodeRenderer renders tables laid out by Lines for the goldmark-markdown
+// renderer, which otherwise falls back to the HTML table renderer of the
+// GFM extension.
+type NodeRenderer struct{}
+
+// NewNodeRenderer returns the table renderer as a renderer option, to be
+// added after the GFM extension has registered its own.
+func NewNodeRenderer() renderer.Option {
+	return renderer.WithNodeRenderers(util.Prioritized(NodeRenderer{}, 100))
+}
+
+func (r NodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(east.KindTable, r.renderTable)
+}
+
+func (r NodeRenderer) renderTable(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	var b bytes.Buffer
+	if node.PreviousSibling() != nil {
+		b.WriteString("\n")
+	}
+	b.WriteString(FromNode(node.(*east.Table), source).Markdown())
+	// Write, unlike WriteString, emits whole lines with their container
+	// prefixes in the goldmark-markdown writer.
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return ast.WalkStop, err
+	}
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,156 @@
+// Package table reads, lays out and converts GFM pipe tables. Column
+// widths count East Asian wide and fullwidth characters as two cells and
+// combining marks as none, so pipes line up in a terminal.
+package table
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"golang.org/x/text/width"
+
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// Table holds the markdown source of each cell. Heading is the text of the
+// heading before the table in its document, if any.
+type Table struct {
+	Header  []string
+	Align   []east.Alignment
+	Rows    [][]string
+	Heading string
+}
+
+// Width returns the number of terminal cells s occupies.
+func Width(s string) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		case width.LookupRune(r).Kind() == width.EastAsianWide, width.LookupRune(r).Kind() == width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// FromNode reads a parsed table.
+func FromNode(n *east.Table, source []byte) *Table {
+	t := &Table{Align: append([]east.Alignment{}, n.Alignments...)}
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, string(cell.Lines().Value(source)))
+		}
+		if row.Kind() == east.KindTableHeader {
+			t.Header = cells
+		} else {
+			t.Rows = append(t.Rows, cells)
+		}
+	}
+	return t
+}
+
+// Find returns the tables of doc in document order.
+func Find(doc ast.Node, source []byte) []*Table {
+	var tables []*Table
+	heading := ""
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			heading = mdast.PlainText(source, node)
+		case *east.Table:
+			t := FromNode(node, source)
+			t.Heading = heading
+			tables = append(tables, t)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return tables
+}
+
+func (t *Table) columns() int {
+	n := len(t.Header)
+	for _, row := range t.Rows {
+		n = max(n, len(row))
+	}
+	return n
+}
+
+func (t *Table) alignment(i int) east.Alignment {
+	if i < len(t.Align) {
+		return t.Align[i]
+	}
+	return east.AlignNone
+}
+
+// Lines lays the table out with aligned pipes and a normalized delimiter
+// row: every column is at least three cells wide and the delimiter row is
+// dashes with colons only where the column is aligned.
+func (t *Table) Lines() []string {
+	n := t.columns()
+	widths := make([]int, n)
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], Width(cell))
+		}
+	}
+
+	line := func(row []string) string {
+		var b strings.Builder
+		b.WriteString("|")
+		for i, w := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			pad := w - Width(cell)
+			left := 0
+			switch t.alignment(i) {
+			case east.AlignRight:
+				left = pad
+			case east.AlignCenter:
+				left = pad / 2
+			}
+			b.WriteString(" " + strings.Repeat(" ", left) + cell + strings.Repeat(" ", pad-left) + " |")
+		}
+		return b.String()
+	}
+
+	lines := []string{line(t.Header)}
+	var b strings.Builder
+	b.WriteString("|")
+	for i, w := range widths {
+		switch t.alignment(i) {
+		case east.AlignLeft:
+			b.WriteString(" :" + strings.Repeat("-", w-1) + " |")
+		case east.AlignRight:
+			b.WriteString(" " + strings.Repeat("-", w-1) + ": |")
+		case east.AlignCenter:
+			b.WriteString(" :" + strings.Repeat("-", w-2) + ": |")
+		default:
+			b.WriteString(" " + strings.Repeat("-", w) + " |")
+		}
+	}
+	lines = append(lines, b.String())
+	for _, row := range t.Rows {
+		lines = append(lines, line(row))
+	}
+	return lines
+}
+
+// Markdown returns the lines of the table, each ending in a newline.
+func (t *Table) Markdown() string {
+	return strings.Join(t.Lines(), "\n") + "\n"
+}
@@ -0,0 +1,109 @@
+package table
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	markdown "github.com/teekennedy/goldmark-markdown"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+)
+
+func TestWidth(t *testing.T) {
+	for s, want := range map[string]int{
+		"abc": 3,
+		"日本語": 6,
+		"ｱｲｳ": 3,
+		"Ａ":   2,
+		"é":  1,
+		"":    0,
+	} {
+		if got := Width(s); got != want {
+			t.Errorf("Width(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	source := "# Data\n\nText  with  spaces |\n\n|a|b|c|d|\n|:-|-:|:-:|-|\n|日本語|1|x|\n|`a\\|b`|22|yy|z|\n\n> | q | r |\n> |---|---|\n> | long value | s |\n\n- item\n\n  |k|\n  |--|\n  |v|\n"
+	want := "# Data\n\nText  with  spaces |\n\n" +
+		"| a      |   b |  c  | d   |\n" +
+		"| :----- | --: | :-: | --- |\n" +
+		"| 日本語 |   1 |  x  |     |\n" +
+		"| `a\\|b` |  22 | yy  | z   |\n\n" +
+		"> | q          | r   |\n" +
+		"> | ---------- | --- |\n" +
+		"> | long value | s   |\n\n" +
+		"- item\n\n" +
+		"  | k   |\n" +
+		"  | --- |\n" +
+		"  | v   |\n"
+	got := Format([]byte(source))
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Format mismatch (-want +got):\n%s", diff)
+	}
+	if again := Format(got); !bytes.Equal(again, got) {
+		t.Errorf("Format is not idempotent:\n%s", again)
+	}
+}
+
+func TestNodeRenderer(t *testing.T) {
+	md := core.NewGoldmark(goldmark.WithRenderer(markdown.NewRenderer()))
+	md.Renderer().AddOptions(NewNodeRenderer())
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte("Intro\n\n> |a|b|\n> |-|:-:|\n> |長い|c|\n"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "Intro\n\n> | a    |  b  |\n> | ---- | :-: |\n> | 長い |  c  |\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("render mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	csvTable, err := ReadCSV(strings.NewReader("name,count\n東京,\"1,200\"\n\"a|b\",3\n"), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "| name | count |\n| ---- | ----: |\n| 東京 | 1,200 |\n| a\\|b |     3 |\n"
+	if diff := cmp.Diff(want, csvTable.Markdown()); diff != "" {
+		t.Errorf("CSV table mismatch (-want +got):\n%s", diff)
+	}
+
+	jsonTable, err := ReadJSON(strings.NewReader(`[{"id": 1, "tags": ["x"], "note": null}, {"id": 22, "name": "two\nlines"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "|  id | tags  | note | name         |\n| --: | ----- | ---- | ------------ |\n|   1 | [\"x\"] |      |              |\n|  22 |       |      | two<br>lines |\n"
+	if diff := cmp.Diff(want, jsonTable.Markdown()); diff != "" {
+		t.Errorf("JSON table mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := ReadJSON(strings.NewReader(`{"a": 1}`)); err == nil {
+		t.Error("ReadJSON accepted an object")
+	}
+
+	source := []byte("# Results\n\n| name | count |\n| ---- | ----: |\n| a\\|b | 3 |\n| c |\n")
+	tables := Find(core.NewGoldmark().Parser().Parse(text.NewReader(source)), source)
+	if len(tables) != 1 || tables[0].Heading != "Results" {
+		t.Fatalf("Find = %+v", tables)
+	}
+	var csvOut, jsonOut bytes.Buffer
+	if err := tables[0].WriteCSV(&csvOut, ','); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("name,count\na|b,3\nc,\n", csvOut.String()); diff != "" {
+		t.Errorf("CSV mismatch (-want +got):\n%s", diff)
+	}
+	if err := tables[0].WriteJSON(&jsonOut); err != nil {
+		t.Fatal(err)
+	}
+	want = "[\n  {\"name\": \"a|b\", \"count\": \"3\"},\n  {\"name\": \"c\", \"count\": \"\"}\n]\n"
+	if diff := cmp.Diff(want, jsonOut.String()); diff != "" {
+		t.Errorf("JSON mismatch (-want +got):\n%s", diff)
+	}
+}
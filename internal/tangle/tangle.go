@@ -0,0 +1,199 @@
+// Package tangle collects the fenced code blocks of markdown documents into
+// source files, so programs kept in documentation can be built and tested.
+package tangle
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/codeblock"
+	"github.com/gkwa/bravewaldo/internal/eol"
+)
+
+// FileAttr names the info string attribute that sets a block's target.
+const FileAttr = "file"
+
+type Options struct {
+	// Languages limits tangling to blocks of these languages. Blocks of
+	// these languages without a file attribute go to Output.
+	Languages []string
+	// Output is the target of the selected blocks without a file
+	// attribute. When empty it is the document name with the extension of
+	// the block's language.
+	Output string
+	// Dir is the directory targets are relative to. When empty they are
+	// relative to the directory of their document.
+	Dir string
+	// LineDirectives precedes each block with a directive or comment that
+	// points back to its line in the markdown document.
+	LineDirectives bool
+	// LineEnding, when set, is the line ending of the tangled files.
+	// Otherwise they get that of the first document with blocks for them.
+	LineEnding string
+}
+
+// File is a tangled target and the blocks that make it up.
+type File struct {
+	Path    string
+	Content []byte
+	Blocks  int
+}
+
+// extensions maps languages to the file extension of their sources.
+var extensions = map[string]string{
+	"bash":       ".sh",
+	"c":          ".c",
+	"cpp":        ".cpp",
+	"go":         ".go",
+	"javascript": ".js",
+	"js":         ".js",
+	"python":     ".py",
+	"py":         ".py",
+	"ruby":       ".rb",
+	"rust":       ".rs",
+	"sh":         ".sh",
+	"shell":      ".sh",
+	"typescript": ".ts",
+	"ts":         ".ts",
+	"yaml":       ".yaml",
+}
+
+// Tangle reads the markdown files at paths and returns the files their
+// blocks make up, in order of first appearance. Blocks with the same
+// target are concatenated in document order.
+func Tangle(paths []string, opts Options) ([]File, error) {
+	var files []File
+	var endings []string
+	index := map[string]int{}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		source, style := eol.Normalize(raw)
+		doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+
+		for _, block := range codeblock.Blocks(doc, source) {
+			target, ok := targetOf(path, block.Info, opts)
+			if !ok {
+				continue
+			}
+			i, seen := index[target]
+			if !seen {
+				i = len(files)
+				index[target] = i
+				files = append(files, File{Path: target})
+				endings = append(endings, style.WithLineEnding(opts.LineEnding).LineEnding)
+			}
+
+			var b bytes.Buffer
+			content := block.Content
+			if opts.LineDirectives {
+				// A directive must not come before a #! line.
+				line := block.Line
+				if bytes.HasPrefix(content, []byte("#!")) {
+					end := bytes.IndexByte(content, '\n') + 1
+					if end == 0 {
+						end = len(content)
+					}
+					b.Write(content[:end])
+					content = content[end:]
+					line++
+				}
+				b.WriteString(lineDirective(block.Info.Language, relativeTo(target, path), line))
+			}
+			b.Write(content)
+			if len(block.Content) > 0 && !bytes.HasSuffix(block.Content, []byte("\n")) {
+				b.WriteByte('\n')
+			}
+			files[i].Content = append(files[i].Content, b.Bytes()...)
+			files[i].Blocks++
+		}
+	}
+	for i := range files {
+		files[i].Content = eol.Convert(files[i].Content, endings[i])
+	}
+	return files, nil
+}
+
+// targetOf returns the path a block of the document at path is tangled to.
+func targetOf(path string, info codeblock.Info, opts Options) (string, bool) {
+	if len(opts.Languages) > 0 && !slices.Contains(opts.Languages, info.Language) {
+		return "", false
+	}
+	name := info.Attrs[FileAttr]
+	if name == "" {
+		if len(opts.Languages) == 0 {
+			return "", false
+		}
+		name = opts.Output
+		if name == "" {
+			ext, ok := extensions[info.Language]
+			if !ok {
+				ext = "." + info.Language
+			}
+			base := filepath.Base(path)
+			name = base[:len(base)-len(filepath.Ext(base))] + ext
+		}
+	}
+
+	if filepath.IsAbs(name) {
+		return name, true
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)), true
+}
+
+// relativeTo returns the path of document relative to the directory of
+// target, which is how Go resolves relative //line file names.
+func relativeTo(target, document string) string {
+	absTarget, err1 := filepath.Abs(target)
+	absDocument, err2 := filepath.Abs(document)
+	if err1 != nil || err2 != nil {
+		return document
+	}
+	rel, err := filepath.Rel(filepath.Dir(absTarget), absDocument)
+	if err != nil {
+		return absDocument
+	}
+	return rel
+}
+
+// lineDirective returns a line that makes compilers report positions in
+// the markdown source, or a comment naming it for other languages.
+func lineDirective(language, path string, line int) string {
+	path = filepath.ToSlash(path)
+	switch language {
+	case "go":
+		return fmt.Sprintf("//line %s:%d\n", path, line)
+	case "c", "cpp", "c++":
+		return fmt.Sprintf("#line %d %q\n", line, path)
+	case "bash", "sh", "shell", "python", "py", "ruby", "yaml", "toml", "perl":
+		return fmt.Sprintf("# %s:%d\n", path, line)
+	case "sql", "lua", "haskell":
+		return fmt.Sprintf("-- %s:%d\n", path, line)
+	}
+	return fmt.Sprintf("// %s:%d\n", path, line)
+}
+
+// Write writes each file, creating directories as needed.
+func Write(files []File) error {
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(f.Path, f.Content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package tangle
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const doc = "# Example\n\n" +
+	"```go file=main.go\npackage main\n\nimport \"fmt\"\n```\n\n" +
+	"Some prose.\n\n" +
+	"```go file=main.go\nfunc main() {\n\tfmt.Println(greeting)\n}\n```\n\n" +
+	"```go\nconst greeting = \"hi\"\n```\n\n" +
+	"```sh\n#!/bin/sh\necho hi\n```\n"
+
+func TestTangle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "docs", "example.md")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Tangle([]string{path}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []File{{
+		Path:    filepath.Join(dir, "docs", "main.go"),
+		Content: []byte("package main\n\nimport \"fmt\"\nfunc main() {\n\tfmt.Println(greeting)\n}\n"),
+		Blocks:  2,
+	}}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Errorf("Tangle mismatch (-want +got):\n%s", diff)
+	}
+
+	out := filepath.Join(dir, "out")
+	files, err = Tangle([]string{path}, Options{Languages: []string{"go", "sh"}, Dir: out, LineDirectives: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(files); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if diff := cmp.Diff([]string{filepath.Join(out, "main.go"), filepath.Join(out, "example.go"), filepath.Join(out, "example.sh")}, paths); diff != "" {
+		t.Errorf("targets mismatch (-want +got):\n%s", diff)
+	}
+
+	script, err := os.ReadFile(filepath.Join(out, "example.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("#!/bin/sh\n# ../docs/example.md:23\necho hi\n", string(script)); diff != "" {
+		t.Errorf("script mismatch (-want +got):\n%s", diff)
+	}
+
+	// The //line directives make the Go toolchain report markdown positions.
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(out, "main.go"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := fset.Position(file.Decls[1].Pos())
+	if pos.Filename != path || pos.Line != 12 {
+		t.Errorf("func main is at %s:%d, want %s:12", pos.Filename, pos.Line, path)
+	}
+}
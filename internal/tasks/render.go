@@ -0,0 +1,107 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Formats lists the output formats Write accepts.
+var Formats = []string{FormatJSON, FormatMarkdown}
+
+// Write writes tasks to w in format.
+func Write(w io.Writer, format string, tasks []Task, now time.Time) error {
+	switch format {
+	case FormatJSON:
+		if tasks == nil {
+			tasks = []Task{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tasks)
+	case FormatMarkdown:
+		_, err := io.WriteString(w, Markdown(tasks, now))
+		return err
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// Markdown returns a summary page: the overall completion, then each file
+// with its completion and its tasks, grouped by the heading path they are
+// under.
+func Markdown(tasks []Task, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("# Tasks\n\n")
+	if len(tasks) == 0 {
+		b.WriteString("No tasks.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s done.\n", progress(tasks))
+	if overdue := countIf(tasks, func(t Task) bool { return t.Overdue(now) }); overdue > 0 {
+		fmt.Fprintf(&b, "%d open %s overdue.\n", overdue, plural(overdue, "task is", "tasks are"))
+	}
+
+	for i := 0; i < len(tasks); {
+		j := i
+		for j < len(tasks) && tasks[j].Path == tasks[i].Path {
+			j++
+		}
+		file := tasks[i:j]
+		fmt.Fprintf(&b, "\n## `%s`\n\n%s done.\n", file[0].Path, progress(file))
+
+		section := ""
+		blank := true
+		for _, t := range file {
+			if s := strings.Join(t.Headings, " › "); s != section {
+				section = s
+				fmt.Fprintf(&b, "\n### %s\n", s)
+				blank = true
+			}
+			if blank {
+				b.WriteString("\n")
+				blank = false
+			}
+			box := " "
+			if t.Done {
+				box = "x"
+			}
+			fmt.Fprintf(&b, "- [%s] %s (`%s:%d`, `%s`)", box, t.Text, t.Path, t.Line, t.ID)
+			if t.Overdue(now) {
+				b.WriteString(" **overdue**")
+			}
+			b.WriteString("\n")
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// progress returns the completion of tasks, such as "3 of 4 tasks (75%)".
+func progress(tasks []Task) string {
+	done := countIf(tasks, func(t Task) bool { return t.Done })
+	return fmt.Sprintf("%d of %d %s (%d%%)", done, len(tasks), plural(len(tasks), "task", "tasks"), done*100/len(tasks))
+}
+
+func countIf(tasks []Task, f func(Task) bool) int {
+	n := 0
+	for _, t := range tasks {
+		if f(t) {
+			n++
+		}
+	}
+	return n
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
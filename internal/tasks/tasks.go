@@ -0,0 +1,187 @@
+// Package tasks collects the GFM task list items of markdown documents,
+// - [ ] and - [x], with the headings they are under and the @owner and
+// due:YYYY-MM-DD tokens in their text, and toggles them in place.
+package tasks
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/files"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+var (
+	ownerRegex = regexp.MustCompile(`(?:^|\s)@([\w][\w.-]*)`)
+	dueRegex   = regexp.MustCompile(`(?:^|\s)due:(\d{4}-\d{2}-\d{2})\b`)
+)
+
+// Task is a task list item. ID stays the same when the task is toggled or
+// moves to another line, as long as its file and text do not change.
+type Task struct {
+	ID       string   `json:"id"`
+	Path     string   `json:"path"`
+	Line     int      `json:"line"`
+	Done     bool     `json:"done"`
+	Text     string   `json:"text"`
+	Headings []string `json:"headings"`
+	Owners   []string `json:"owners,omitempty"`
+	Due      string   `json:"due,omitempty"`
+
+	// offset is the byte of the checkbox that holds the state.
+	offset int
+}
+
+// Collect returns the tasks of the markdown files in paths, in document
+// order.
+func Collect(paths []string) ([]Task, error) {
+	docs, err := files.Markdown(paths)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	for _, path := range docs {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		tasks = append(tasks, Parse(filepath.ToSlash(path), source)...)
+	}
+	return tasks, nil
+}
+
+// Parse returns the tasks of source, the document at path.
+func Parse(path string, source []byte) []Task {
+	doc := core.NewGoldmark().Parser().Parse(text.NewReader(source))
+	var tasks []Task
+	var headings []*ast.Heading
+	seen := map[string]int{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if h, ok := n.(*ast.Heading); ok {
+			for len(headings) > 0 && headings[len(headings)-1].Level >= h.Level {
+				headings = headings[:len(headings)-1]
+			}
+			headings = append(headings, h)
+			return ast.WalkSkipChildren, nil
+		}
+		box, ok := n.(*extast.TaskCheckBox)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		// The checkbox has no position of its own; it starts the first
+		// line of its text block.
+		block := box.Parent()
+		lines := block.Lines()
+		if lines.Len() == 0 || source[lines.At(0).Start] != '[' {
+			return ast.WalkContinue, nil
+		}
+		offset := lines.At(0).Start + 1
+
+		taskText := strings.TrimSpace(mdast.PlainText(source, block))
+		task := Task{
+			Path:     path,
+			Line:     mdast.PositionOf(source, offset).Line,
+			Done:     box.IsChecked,
+			Text:     taskText,
+			Headings: []string{},
+			offset:   offset,
+		}
+		for _, h := range headings {
+			task.Headings = append(task.Headings, mdast.PlainText(source, h))
+		}
+		for _, m := range ownerRegex.FindAllStringSubmatch(taskText, -1) {
+			task.Owners = append(task.Owners, m[1])
+		}
+		if m := dueRegex.FindStringSubmatch(taskText); m != nil {
+			if _, err := time.Parse(time.DateOnly, m[1]); err == nil {
+				task.Due = m[1]
+			}
+		}
+
+		sum := sha1.Sum([]byte(path + "\x00" + taskText))
+		task.ID = hex.EncodeToString(sum[:])[:7]
+		if seen[task.ID]++; seen[task.ID] > 1 {
+			task.ID += "-" + strconv.Itoa(seen[task.ID])
+		}
+		tasks = append(tasks, task)
+		return ast.WalkContinue, nil
+	})
+	return tasks
+}
+
+// Overdue reports whether the task is open and was due before now.
+func (t Task) Overdue(now time.Time) bool {
+	if t.Done || t.Due == "" {
+		return false
+	}
+	due, err := time.Parse(time.DateOnly, t.Due)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return err == nil && due.Before(today)
+}
+
+// Matches reports whether id names the task, either by its ID or as
+// path:line.
+func (t Task) Matches(id string) bool {
+	return id == t.ID || id == fmt.Sprintf("%s:%d", t.Path, t.Line)
+}
+
+// Toggle flips the tasks named by ids among those in paths and writes the
+// files they are in. Only the checkbox character changes. It returns the
+// toggled tasks with their new state.
+func Toggle(paths []string, ids []string) ([]Task, error) {
+	tasks, err := Collect(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var toggled []Task
+	byPath := map[string][]Task{}
+	done := map[string]bool{}
+	for _, id := range ids {
+		i := slices.IndexFunc(tasks, func(t Task) bool { return t.Matches(id) })
+		if i < 0 {
+			return nil, fmt.Errorf("no task %q", id)
+		}
+		t := tasks[i]
+		if done[t.Path+"\x00"+t.ID] {
+			continue
+		}
+		done[t.Path+"\x00"+t.ID] = true
+		t.Done = !t.Done
+		byPath[t.Path] = append(byPath[t.Path], t)
+		toggled = append(toggled, t)
+	}
+
+	for path, changed := range byPath {
+		source, err := os.ReadFile(filepath.FromSlash(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for _, t := range changed {
+			source[t.offset] = ' '
+			if t.Done {
+				source[t.offset] = 'x'
+			}
+		}
+		if err := os.WriteFile(filepath.FromSlash(path), source, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return toggled, nil
+}
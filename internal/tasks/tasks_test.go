@@ -0,0 +1,103 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const doc = `# Plan
+
+- [x] Write the parser @ana
+- [ ] Ship it @ana @bo due:2024-03-01
+
+## Later
+
+> - [ ] Quoted task due:2099-01-01
+- [ ] Write the parser
+- Not a task
+`
+
+func TestParse(t *testing.T) {
+	got := Parse("plan.md", []byte(doc))
+	want := []Task{
+		{Path: "plan.md", Line: 3, Done: true, Text: "Write the parser @ana", Headings: []string{"Plan"}, Owners: []string{"ana"}},
+		{Path: "plan.md", Line: 4, Text: "Ship it @ana @bo due:2024-03-01", Headings: []string{"Plan"}, Owners: []string{"ana", "bo"}, Due: "2024-03-01"},
+		{Path: "plan.md", Line: 8, Text: "Quoted task due:2099-01-01", Headings: []string{"Plan", "Later"}, Due: "2099-01-01"},
+		{Path: "plan.md", Line: 9, Text: "Write the parser", Headings: []string{"Plan", "Later"}},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(Task{}, "ID"), cmpopts.IgnoreUnexported(Task{})); diff != "" {
+		t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+	}
+
+	ids := map[string]bool{}
+	for _, task := range got {
+		if len(task.ID) < 7 || ids[task.ID] {
+			t.Errorf("task %q has ID %q, want a unique ID", task.Text, task.ID)
+		}
+		ids[task.ID] = true
+	}
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	if !got[1].Overdue(now) || got[2].Overdue(now) || got[0].Overdue(now) {
+		t.Error("Overdue() does not match the due dates")
+	}
+}
+
+func TestToggle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.md")
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before := Parse(filepath.ToSlash(path), []byte(doc))
+
+	toggled, err := Toggle([]string{dir}, []string{before[0].ID, filepath.ToSlash(path) + ":8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toggled) != 2 || toggled[0].Done || !toggled[1].Done {
+		t.Errorf("Toggle() = %+v, want the first task open and the third done", toggled)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := doc
+	want = want[:len("# Plan\n\n- [")] + " " + want[len("# Plan\n\n- [x"):]
+	want = strings.Replace(want, "> - [ ]", "> - [x]", 1)
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("toggled file mismatch (-want +got):\n%s", diff)
+	}
+
+	after := Parse(filepath.ToSlash(path), got)
+	if after[0].ID != before[0].ID {
+		t.Errorf("toggling changed the ID from %q to %q", before[0].ID, after[0].ID)
+	}
+
+	if _, err := Toggle([]string{dir}, []string{"nope"}); err == nil {
+		t.Error("Toggle() of an unknown ID succeeded")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	tasks := Parse("plan.md", []byte(doc))
+	got := Markdown(tasks, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	want := "# Tasks\n\n1 of 4 tasks (25%) done.\n1 open task is overdue.\n\n" +
+		"## `plan.md`\n\n1 of 4 tasks (25%) done.\n\n" +
+		"### Plan\n\n" +
+		"- [x] Write the parser @ana (`plan.md:3`, `" + tasks[0].ID + "`)\n" +
+		"- [ ] Ship it @ana @bo due:2024-03-01 (`plan.md:4`, `" + tasks[1].ID + "`) **overdue**\n\n" +
+		"### Plan › Later\n\n" +
+		"- [ ] Quoted task due:2099-01-01 (`plan.md:8`, `" + tasks[2].ID + "`)\n" +
+		"- [ ] Write the parser (`plan.md:9`, `" + tasks[3].ID + "`)\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Markdown() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,35 @@
+package urlmap
+
+import (
+	"fmt"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Default returns the URL map used when no URL map file is configured.
+func Default() map[string]string {
+	return map[string]string{
+		"https://example.com":          "sample website",
+		"https://google.com":           "search engine",
+		"http://test.org":              "testing site",
+		"https://github.com/user/repo": "code repository",
+	}
+}
+
+// Load reads a YAML file that maps URLs to friendly names, for example:
+//
+//	https://example.com: sample website
+//	https://google.com: search engine
+func Load(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read url map: %w", err)
+	}
+
+	m := map[string]string{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse url map %s: %w", path, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,194 @@
+// Package vars replaces {{ .name }} placeholders in markdown with values
+// from the document's front matter, the vars section of the config file
+// and prefixed environment variables. Nested values are reached with dots,
+// as in {{ .site.url }}.
+package vars
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/codeblock"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// DefaultEnvPrefix is the prefix of environment variables that provide
+// values, so BRAVEWALDO_VAR_version sets {{ .version }}.
+const DefaultEnvPrefix = "BRAVEWALDO_VAR_"
+
+var placeholderRegex = regexp.MustCompile(`\{\{\s*\.([A-Za-z_][\w-]*(?:\.[A-Za-z_][\w-]*)*)\s*\}\}`)
+
+var frontMatterRegex = regexp.MustCompile(`(?s)\A---\r?\n.*?\r?\n---\r?\n`)
+
+type Options struct {
+	// Vars are the values from the config file.
+	Vars map[string]any
+	// EnvPrefix selects the environment variables that provide values.
+	// Empty means no environment variables.
+	EnvPrefix string
+	// Code also substitutes placeholders in code spans and code blocks.
+	Code bool
+}
+
+// Result is a document after substitution. Missing lists the names of
+// placeholders without a value, which are left as they are.
+type Result struct {
+	Output  []byte
+	Missing []string
+}
+
+// Substitute replaces the placeholders of source outside its front matter
+// and, unless opts.Code is set, outside code. Values from the front matter
+// take precedence over environment variables, which take precedence over
+// config vars.
+func Substitute(source []byte, opts Options) *Result {
+	pc := parser.NewContext()
+	doc := core.NewGoldmark().Parser().Parse(text.NewReader(source), parser.WithContext(pc))
+
+	values := map[string]any{}
+	for k, v := range opts.Vars {
+		values[k] = v
+	}
+	if opts.EnvPrefix != "" {
+		for _, kv := range os.Environ() {
+			name, value, _ := strings.Cut(kv, "=")
+			if key, ok := strings.CutPrefix(name, opts.EnvPrefix); ok && key != "" {
+				values[key] = value
+			}
+		}
+	}
+	for k, v := range meta.Get(pc) {
+		values[k] = v
+	}
+
+	var skip []mdast.Span
+	if m := frontMatterRegex.FindIndex(source); m != nil {
+		skip = append(skip, mdast.Span{Start: m[0], Stop: m[1]})
+	}
+	if !opts.Code {
+		skip = append(skip, codeSpans(doc, source)...)
+	}
+
+	result := &Result{}
+	missing := map[string]bool{}
+	last := 0
+	for _, m := range placeholderRegex.FindAllSubmatchIndex(source, -1) {
+		if skipped(skip, m[0]) {
+			continue
+		}
+		name := string(source[m[2]:m[3]])
+		value, ok := lookup(values, name)
+		if !ok {
+			missing[name] = true
+			continue
+		}
+		result.Output = append(result.Output, source[last:m[0]]...)
+		result.Output = append(result.Output, fmt.Sprint(value)...)
+		last = m[1]
+	}
+	result.Output = append(result.Output, source[last:]...)
+
+	for name := range missing {
+		result.Missing = append(result.Missing, name)
+	}
+	sort.Strings(result.Missing)
+	return result
+}
+
+// lookup follows the dotted name through nested maps. Names are matched
+// exactly first, then ignoring case, so environment variables written in
+// upper case still match.
+func lookup(values map[string]any, name string) (any, bool) {
+	var current any = values
+	for _, key := range strings.Split(name, ".") {
+		m, ok := asMap(current)
+		if !ok {
+			return nil, false
+		}
+		value, ok := m[key]
+		if !ok {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if strings.EqualFold(k, key) {
+					value, ok = m[k], true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+		current = value
+	}
+	if _, isMap := asMap(current); isMap {
+		return nil, false
+	}
+	return current, true
+}
+
+// asMap accepts both the map types of YAML front matter and of viper.
+func asMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		result := make(map[string]any, len(m))
+		for k, v := range m {
+			result[fmt.Sprint(k)] = v
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// codeSpans returns the source spans of code spans and code blocks,
+// including fences.
+func codeSpans(doc ast.Node, source []byte) []mdast.Span {
+	var spans []mdast.Span
+	for _, block := range codeblock.Blocks(doc, source) {
+		spans = append(spans, block.Span)
+	}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindCodeSpan:
+			for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					spans = append(spans, mdast.Span{Start: t.Segment.Start, Stop: t.Segment.Stop})
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case ast.KindCodeBlock:
+			if lines := n.Lines(); lines.Len() > 0 {
+				spans = append(spans, mdast.Span{Start: lines.At(0).Start, Stop: lines.At(lines.Len() - 1).Stop})
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return spans
+}
+
+func skipped(spans []mdast.Span, offset int) bool {
+	for _, s := range spans {
+		if s.Start <= offset && offset < s.Stop {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package vars
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSubstitute(t *testing.T) {
+	t.Setenv("TEST_VAR_version", "2.0")
+	t.Setenv("TEST_VAR_HOST", "example.org")
+
+	source := "---\ntitle: Guide\nsite:\n  repo: gkwa/bravewaldo\n---\n" +
+		"# {{ .title }} {{.version}}\n\n" +
+		"Get it from [GitHub](https://github.com/{{ .site.repo }}) or {{ .host }}.\n\n" +
+		"Run `install {{ .version }}`.\n\n" +
+		"```sh\necho {{ .version }}\n```\n\n" +
+		"    {{ .version }}\n\n" +
+		"Unknown {{ .nope }} and {{ .site.missing }}.\n"
+
+	opts := Options{
+		Vars:      map[string]any{"version": "1.0", "owner": "gkwa"},
+		EnvPrefix: "TEST_VAR_",
+	}
+	got := Substitute([]byte(source), opts)
+	want := "---\ntitle: Guide\nsite:\n  repo: gkwa/bravewaldo\n---\n" +
+		"# Guide 2.0\n\n" +
+		"Get it from [GitHub](https://github.com/gkwa/bravewaldo) or example.org.\n\n" +
+		"Run `install {{ .version }}`.\n\n" +
+		"```sh\necho {{ .version }}\n```\n\n" +
+		"    {{ .version }}\n\n" +
+		"Unknown {{ .nope }} and {{ .site.missing }}.\n"
+	if diff := cmp.Diff(want, string(got.Output)); diff != "" {
+		t.Errorf("Substitute() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"nope", "site.missing"}, got.Missing); diff != "" {
+		t.Errorf("Missing mismatch (-want +got):\n%s", diff)
+	}
+
+	opts.Code = true
+	got = Substitute([]byte("Run `v{{ .version }}`.\n\n```\n{{ .owner }}\n```\n"), opts)
+	if want := "Run `v2.0`.\n\n```\ngkwa\n```\n"; string(got.Output) != want {
+		t.Errorf("Substitute() with Code = %q, want %q", got.Output, want)
+	}
+}
+
+func TestSubstitutePrecedence(t *testing.T) {
+	t.Setenv("TEST_VAR_name", "env")
+	tests := []struct {
+		source string
+		opts   Options
+		want   string
+	}{
+		{"{{ .name }}\n", Options{Vars: map[string]any{"name": "config"}}, "config\n"},
+		{"{{ .name }}\n", Options{Vars: map[string]any{"name": "config"}, EnvPrefix: "TEST_VAR_"}, "env\n"},
+		{"---\nname: front\n---\n{{ .name }}\n", Options{EnvPrefix: "TEST_VAR_"}, "---\nname: front\n---\nfront\n"},
+		{"{{ .a.b }}\n", Options{Vars: map[string]any{"a": map[string]any{"b": 1}}}, "1\n"},
+		{"{{ .a }}\n", Options{Vars: map[string]any{"a": map[string]any{"b": 1}}}, "{{ .a }}\n"},
+	}
+	for _, tt := range tests {
+		got := Substitute([]byte(tt.source), tt.opts)
+		if string(got.Output) != tt.want {
+			t.Errorf("Substitute(%q) = %q, want %q", tt.source, got.Output, tt.want)
+		}
+	}
+}
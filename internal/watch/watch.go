@@ -0,0 +1,313 @@
+package watch
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+
+	"github.com/gkwa/bravewaldo/internal/files"
+)
+
+const DefaultDebounce = 200 * time.Millisecond
+
+type Options struct {
+	// Debounce is how long the watcher waits for events to settle before
+	// reprocessing. Editors often write a file several times per save.
+	Debounce time.Duration
+	// Extra lists files such as the config or URL map file. A change to
+	// any of them reprocesses every input.
+	Extra []string
+	// Reload is called before reprocessing after an extra file changed.
+	Reload func() error
+	// AllFiles makes every file under the input directories an input,
+	// not only markdown, except dotfiles. Run reports changes to them but
+	// a reload still reprocesses only the markdown files.
+	AllFiles bool
+}
+
+// Watcher reprocesses markdown inputs when they change on disk.
+type Watcher struct {
+	logger  logr.Logger
+	inputs  []string
+	options Options
+
+	fsw    *fsnotify.Watcher
+	files  map[string]bool
+	dirs   []string
+	extra  map[string]bool
+	hashes map[string][32]byte
+
+	// processed, when set, is called after each batch of changes has been
+	// processed and remembered.
+	processed func()
+}
+
+// New watches inputs, the markdown files and directories to reprocess, and
+// records their content. Changes from then on are picked up by Run.
+func New(logger logr.Logger, inputs []string, options Options) (*Watcher, error) {
+	if options.Debounce <= 0 {
+		options.Debounce = DefaultDebounce
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	w := &Watcher{
+		logger:  logger,
+		inputs:  inputs,
+		options: options,
+		fsw:     fsw,
+		files:   map[string]bool{},
+		extra:   map[string]bool{},
+		hashes:  map[string][32]byte{},
+	}
+
+	if err := w.addInputs(); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	if err := w.Snapshot(); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *Watcher) addInputs() error {
+	watched := map[string]bool{}
+	watchDir := func(dir string) error {
+		if watched[dir] {
+			return nil
+		}
+		watched[dir] = true
+		w.logger.V(1).Info("Watching directory", "dir", dir)
+		return w.fsw.Add(dir)
+	}
+
+	for _, input := range w.inputs {
+		input = filepath.Clean(input)
+		info, err := os.Stat(input)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", input, err)
+		}
+
+		// Watch parent directories rather than files, since many editors
+		// save by writing a temporary file and renaming it into place.
+		if !info.IsDir() {
+			w.files[input] = true
+			if err := watchDir(filepath.Dir(input)); err != nil {
+				return fmt.Errorf("failed to watch %s: %w", input, err)
+			}
+			continue
+		}
+
+		w.dirs = append(w.dirs, input)
+		err = filepath.WalkDir(input, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if p != input && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return watchDir(p)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", input, err)
+		}
+	}
+
+	for _, extra := range w.options.Extra {
+		if extra == "" {
+			continue
+		}
+		extra = filepath.Clean(extra)
+		w.extra[extra] = true
+		if err := watchDir(filepath.Dir(extra)); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", extra, err)
+		}
+	}
+
+	return nil
+}
+
+// Snapshot records the current content of every input. Events for files
+// whose content still matches the snapshot are ignored, which keeps the
+// watcher from reacting to the tool's own writes.
+func (w *Watcher) Snapshot() error {
+	paths, err := w.Inputs()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		w.remember(path)
+	}
+	return nil
+}
+
+// Inputs returns the markdown files currently covered by the watcher.
+func (w *Watcher) Inputs() ([]string, error) {
+	return files.Markdown(w.inputs)
+}
+
+func (w *Watcher) remember(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		delete(w.hashes, path)
+		return
+	}
+	w.hashes[path] = sha256.Sum256(data)
+}
+
+func (w *Watcher) changed(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	old, ok := w.hashes[path]
+	return !ok || old != sha256.Sum256(data)
+}
+
+func (w *Watcher) isInput(path string) bool {
+	if w.files[path] {
+		return true
+	}
+	if w.options.AllFiles {
+		return !strings.HasPrefix(filepath.Base(path), ".") && w.isUnderDir(path)
+	}
+	return files.IsMarkdown(path) && w.isUnderDir(path)
+}
+
+// Run calls process for each changed input until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context, process func(path string) error) error {
+	defer w.fsw.Close()
+
+	pending := map[string]bool{}
+	reload := false
+	timer := time.NewTimer(w.options.Debounce)
+	timer.Stop()
+
+	w.logger.Info("Watching for changes", "inputs", w.inputs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.logger.Error(err, "Watcher error")
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			path := filepath.Clean(event.Name)
+			w.logger.V(1).Info("Event", "path", path, "op", event.Op.String())
+
+			if event.Has(fsnotify.Create) && w.isUnderDir(path) {
+				if info, err := os.Stat(path); err == nil && info.IsDir() {
+					if err := w.fsw.Add(path); err != nil {
+						w.logger.Error(err, "Failed to watch new directory", "dir", path)
+					}
+					continue
+				}
+			}
+
+			switch {
+			case w.extra[path]:
+				reload = true
+			case w.isInput(path):
+				if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+					delete(w.hashes, path)
+					continue
+				}
+				pending[path] = true
+			default:
+				continue
+			}
+			timer.Reset(w.options.Debounce)
+
+		case <-timer.C:
+			paths, err := w.collect(pending, reload)
+			if err != nil {
+				w.logger.Error(err, "Failed to collect changed files")
+			}
+			if reload && w.options.Reload != nil {
+				if err := w.options.Reload(); err != nil {
+					w.logger.Error(err, "Failed to reload")
+				}
+			}
+			pending = map[string]bool{}
+			reload = false
+
+			// Only the processed files are remembered, so edits made to
+			// other files in the meantime are still picked up.
+			for _, path := range paths {
+				w.logger.Info("Processing", "path", path)
+				if err := process(path); err != nil {
+					w.logger.Error(err, "Failed to process file", "path", path)
+				}
+				w.remember(path)
+			}
+			if w.processed != nil {
+				w.processed()
+			}
+		}
+	}
+}
+
+func (w *Watcher) isUnderDir(path string) bool {
+	for _, dir := range w.dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// collect returns the inputs that need reprocessing: every input after a
+// reload, otherwise only pending files whose content really changed.
+func (w *Watcher) collect(pending map[string]bool, reload bool) ([]string, error) {
+	if reload {
+		return w.Inputs()
+	}
+
+	var paths []string
+	if w.options.AllFiles {
+		for path := range pending {
+			if w.changed(path) {
+				paths = append(paths, path)
+			}
+		}
+		slices.Sort(paths)
+		return paths, nil
+	}
+	inputs, err := w.Inputs()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range inputs {
+		if pending[path] && w.changed(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
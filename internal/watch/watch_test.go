@@ -0,0 +1,173 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+type call struct {
+	path    string
+	content string
+}
+
+// receive returns the next value sent on ch, failing the test when none
+// comes in time.
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watcher")
+	}
+	var zero T
+	return zero
+}
+
+func TestRunIgnoresOwnWrites(t *testing.T) {
+	dir := t.TempDir()
+	doc := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(doc, []byte("before\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(logr.Discard(), []string{dir}, Options{Debounce: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := make(chan call, 10)
+	process := func(path string) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		calls <- call{path: path, content: string(content)}
+		// Rewrite the file the way an in-place command would.
+		return os.WriteFile(path, []byte("rewritten\n"), 0o644)
+	}
+	processed := make(chan struct{}, 10)
+	w.processed = func() { processed <- struct{}{} }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx, process) }()
+
+	if err := os.WriteFile(doc, []byte("after\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if c := receive(t, calls); c != (call{doc, "after\n"}) {
+		t.Errorf("first call = %+v, want doc.md with the edit", c)
+	}
+	receive(t, processed)
+
+	// Neither the rewrite nor a file that is not markdown is processed, so
+	// the next call is for the next edit.
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(doc, []byte("again\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if c := receive(t, calls); c != (call{doc, "again\n"}) {
+		t.Errorf("second call = %+v, want doc.md with the second edit", c)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunKeepsEditsDuringProcessing(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, []byte("before\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := New(logr.Discard(), []string{dir}, Options{Debounce: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := make(chan call, 10)
+	process := func(path string) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		calls <- call{path: path, content: string(content)}
+		if path == a {
+			// The user saves another file while a.md is processed.
+			return os.WriteFile(b, []byte("edited\n"), 0o644)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx, process) }()
+
+	if err := os.WriteFile(a, []byte("after\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if c := receive(t, calls); c != (call{a, "after\n"}) {
+		t.Errorf("first call = %+v, want a.md with the edit", c)
+	}
+	if c := receive(t, calls); c != (call{b, "edited\n"}) {
+		t.Errorf("second call = %+v, want b.md with the edit made meanwhile", c)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunAllFiles(t *testing.T) {
+	dir := t.TempDir()
+	style := filepath.Join(dir, "style.css")
+	if err := os.WriteFile(style, []byte("body {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(logr.Discard(), []string{dir}, Options{Debounce: 20 * time.Millisecond, AllFiles: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := make(chan string, 10)
+	process := func(path string) error {
+		paths <- path
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx, process) }()
+
+	// Dotfiles are skipped, so the first call is for the stylesheet.
+	if err := os.WriteFile(filepath.Join(dir, ".doc.md.swp"), []byte("swap\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(style, []byte("body { margin: 0 }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if path := receive(t, paths); path != style {
+		t.Errorf("call = %s, want %s", path, style)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,103 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/spf13/cast"
+)
+
+// Config holds per-rule settings keyed by rule ID, as read from the
+// lint.rules section of .bravewaldo.yaml:
+//
+//	lint:
+//	  rules:
+//	    heading-increment:
+//	      severity: error
+//	    fence-language:
+//	      enabled: false
+//	    list-marker:
+//	      style: "-"
+type Config map[string]RuleConfig
+
+type RuleConfig struct {
+	Enabled  *bool
+	Severity Severity
+	Options  Options
+}
+
+// Options are the rule specific settings of a RuleConfig.
+type Options map[string]any
+
+func (o Options) String(name, fallback string) string {
+	if v, ok := o[name]; ok {
+		return cast.ToString(v)
+	}
+	return fallback
+}
+
+func (o Options) Int(name string, fallback int) int {
+	if v, ok := o[name]; ok {
+		return cast.ToInt(v)
+	}
+	return fallback
+}
+
+// ParseConfig converts the raw lint.rules settings into a Config.
+func ParseConfig(raw map[string]any) (Config, error) {
+	config := Config{}
+	for id, value := range raw {
+		rule, ok := registry[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", id)
+		}
+
+		settings, err := cast.ToStringMapE(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid settings for lint rule %s: %w", id, err)
+		}
+
+		rc := RuleConfig{Options: Options{}}
+		for name, v := range settings {
+			switch name {
+			case "enabled":
+				enabled := cast.ToBool(v)
+				rc.Enabled = &enabled
+			case "severity":
+				severity, err := ParseSeverity(cast.ToString(v))
+				if err != nil {
+					return nil, fmt.Errorf("lint rule %s: %w", id, err)
+				}
+				rc.Severity = severity
+			default:
+				rc.Options[name] = v
+			}
+		}
+		if rule.Validate != nil {
+			if err := rule.Validate(rc.Options); err != nil {
+				return nil, fmt.Errorf("lint rule %s: %w", id, err)
+			}
+		}
+		config[id] = rc
+	}
+	return config, nil
+}
+
+// Only returns a copy of c in which the rules with the given IDs are
+// enabled and every other rule is disabled.
+func (c Config) Only(ids []string) (Config, error) {
+	selected := map[string]bool{}
+	for _, id := range ids {
+		if _, ok := registry[id]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", id)
+		}
+		selected[id] = true
+	}
+	config := Config{}
+	for id := range registry {
+		rc := c[id]
+		enabled := selected[id]
+		rc.Enabled = &enabled
+		config[id] = rc
+	}
+	return config, nil
+}
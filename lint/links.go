@@ -0,0 +1,88 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/gkwa/bravewaldo/internal/linkcheck"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+// The link rules check where links go rather than how they are written:
+// broken-link reports the problems of linkcheck, and inconsistent-label
+// checks link text against the URL map. The language server reports
+// them too.
+func init() {
+	register(Rule{
+		ID:          "broken-link",
+		Description: "Relative links and #anchors should point at files and headings that exist",
+		Severity:    SeverityError,
+		Check:       checkBrokenLink,
+	})
+	register(Rule{
+		ID:          "inconsistent-label",
+		Description: "Links to the same URL should use the same text, preferring the URL map name",
+		Severity:    SeverityInfo,
+		Check:       checkInconsistentLabel,
+	})
+}
+
+func checkBrokenLink(doc *Document, _ Options) []Finding {
+	var findings []Finding
+	for _, p := range linkcheck.Check(doc.Path, doc.Source, doc.Root) {
+		findings = append(findings, Finding{Span: p.Span, Message: p.Message})
+	}
+	return findings
+}
+
+// checkInconsistentLabel flags links whose text differs from the text used
+// for the same URL elsewhere in the document. The URL map name wins when
+// there is one, otherwise the most common label does.
+func checkInconsistentLabel(doc *Document, _ Options) []Finding {
+	byURL := map[string][]mdast.Link{}
+	var order []string
+	for _, link := range mdast.Links(doc.Root, doc.Source) {
+		if _, ok := link.Node.(*ast.Link); !ok || link.Label == "" {
+			continue
+		}
+		key := strings.ToLower(link.URL)
+		if _, seen := byURL[key]; !seen {
+			order = append(order, key)
+		}
+		byURL[key] = append(byURL[key], link)
+	}
+
+	var findings []Finding
+	for _, key := range order {
+		group := byURL[key]
+		counts := map[string]int{}
+		for _, link := range group {
+			counts[link.Label]++
+		}
+		if len(counts) < 2 {
+			continue
+		}
+
+		expected, ok := doc.URLMap[key]
+		if !ok {
+			for _, link := range group {
+				if counts[link.Label] > counts[expected] {
+					expected = link.Label
+				}
+			}
+		}
+
+		for _, link := range group {
+			if link.Label == expected {
+				continue
+			}
+			findings = append(findings, Finding{
+				Span:    link.Span,
+				Message: fmt.Sprintf("link text %q differs from %q used for %s", link.Label, expected, link.URL),
+			})
+		}
+	}
+	return findings
+}
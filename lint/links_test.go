@@ -0,0 +1,49 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLinkRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "links that resolve",
+			input: "# T\n\n[top](#t), [web](https://go.dev/missing.md) and [site](https://example.com)\n",
+		},
+		{
+			name:  "broken link",
+			input: "# T\n\n[gone](missing.md#top) and [web](https://go.dev/missing.md)\n",
+			want:  []string{"3:1 broken-link"},
+		},
+		{
+			name:  "broken reference definition",
+			input: "# T\n\n[gone][ref]\n\n[ref]: ./missing.md\n",
+			want:  []string{"5:1 broken-link"},
+		},
+		{
+			name:  "inconsistent label",
+			input: "# T\n\n[Go](https://go.dev), [Go](https://go.dev), [Golang](https://go.dev) and [site](https://example.com)\n",
+			want:  []string{"3:45 inconsistent-label"},
+		},
+		{
+			name:  "URL map name wins",
+			input: "# T\n\n[site](https://example.com), [site](https://example.com) and [sample website](https://example.com)\n",
+			want:  []string{"3:1 inconsistent-label", "3:30 inconsistent-label"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lintString(t, tt.input, nil)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+func ParseSeverity(s string) (Severity, error) {
+	switch Severity(s) {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return Severity(s), nil
+	}
+	return "", fmt.Errorf("unknown severity %q", s)
+}
+
+// Edit replaces the bytes of Span with NewText.
+type Edit struct {
+	Span    mdast.Span
+	NewText string
+}
+
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Path     string
+	Span     mdast.Span
+	Position mdast.Position
+	// End is the position just past the last character of Span.
+	End     mdast.Position
+	Message string
+	// Fix is nil when the rule cannot fix the finding.
+	Fix *Edit
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s %s %s", f.Path, f.Position.Line, f.Position.Column, f.Severity, f.Rule, f.Message)
+}
+
+// Document is what rules inspect.
+type Document struct {
+	Path   string
+	Source []byte
+	Root   ast.Node
+	URLMap map[string]string
+}
+
+// Rule checks a document. Check returns findings without Rule, Severity,
+// Path or Position set; Lint fills those in.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+	Fixable     bool
+	Check       func(doc *Document, options Options) []Finding
+	// Validate checks the rule specific options, when the rule has any.
+	Validate func(options Options) error
+}
+
+var registry = map[string]Rule{}
+
+func register(rule Rule) {
+	registry[rule.ID] = rule
+}
+
+// Lookup returns the registered rule with the given ID.
+func Lookup(id string) (Rule, bool) {
+	rule, ok := registry[id]
+	return rule, ok
+}
+
+// Rules returns every registered rule sorted by ID.
+func Rules() []Rule {
+	rules := make([]Rule, 0, len(registry))
+	for _, rule := range registry {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+func Parse(path string, source []byte, urlMap map[string]string) *Document {
+	return &Document{
+		Path:   path,
+		Source: source,
+		Root:   core.NewGoldmark().Parser().Parse(text.NewReader(source)),
+		URLMap: urlMap,
+	}
+}
+
+// Lint runs every enabled rule over doc and returns the findings in source
+// order.
+func Lint(doc *Document, config Config) []Finding {
+	var findings []Finding
+	for _, rule := range Rules() {
+		rc := config[rule.ID]
+		if rc.Enabled != nil && !*rc.Enabled {
+			continue
+		}
+		severity := rule.Severity
+		if rc.Severity != "" {
+			severity = rc.Severity
+		}
+
+		for _, f := range rule.Check(doc, rc.Options) {
+			f.Rule = rule.ID
+			f.Severity = severity
+			f.Path = doc.Path
+			f.Position = mdast.PositionOf(doc.Source, f.Span.Start)
+			f.End = mdast.PositionOf(doc.Source, f.Span.Stop)
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Span.Start < findings[j].Span.Start
+	})
+	return findings
+}
+
+// Fix applies the fixes of findings to source. Overlapping fixes after the
+// first are skipped; running Lint and Fix again picks them up.
+func Fix(source []byte, findings []Finding) ([]byte, int) {
+	var edits []Edit
+	for _, f := range findings {
+		if f.Fix != nil {
+			edits = append(edits, *f.Fix)
+		}
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Span.Start < edits[j].Span.Start })
+
+	var out []byte
+	last, applied := 0, 0
+	for _, edit := range edits {
+		if edit.Span.Start < last {
+			continue
+		}
+		out = append(out, source[last:edit.Span.Start]...)
+		out = append(out, edit.NewText...)
+		last = edit.Span.Stop
+		applied++
+	}
+	out = append(out, source[last:]...)
+	return out, applied
+}
+
+// File lints the file at path, applying fixes in place when fix is set.
+// It returns the findings that remain.
+func File(path string, config Config, urlMap map[string]string, fix bool) ([]Finding, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	findings := Lint(Parse(path, source, urlMap), config)
+	if !fix {
+		return findings, nil
+	}
+
+	// Overlapping fixes are applied over several passes.
+	fixed := source
+	for pass := 0; pass < 10; pass++ {
+		var applied int
+		fixed, applied = Fix(fixed, findings)
+		findings = Lint(Parse(path, fixed, urlMap), config)
+		if applied == 0 {
+			break
+		}
+	}
+
+	if string(fixed) != string(source) {
+		if err := os.WriteFile(path, fixed, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,167 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testURLMap = map[string]string{
+	"https://example.com": "sample website",
+}
+
+func lintString(t *testing.T, source string, config Config) []string {
+	t.Helper()
+	var got []string
+	for _, f := range Lint(Parse("doc.md", []byte(source), testURLMap), config) {
+		got = append(got, fmt.Sprintf("%d:%d %s", f.Position.Line, f.Position.Column, f.Rule))
+	}
+	return got
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		config Config
+		want   []string
+	}{
+		{
+			name:  "clean document",
+			input: "# Title\n\n## Section\n\nSee <https://go.dev>.\n\n```go\nx := 1   \n```\n",
+		},
+		{
+			name:  "heading increment",
+			input: "# Title\n\n### Skipped\n",
+			want:  []string{"3:1 heading-increment"},
+		},
+		{
+			name:  "duplicate heading",
+			input: "# Title\n\n## Notes\n\n## Notes\n",
+			want:  []string{"5:1 duplicate-heading"},
+		},
+		{
+			name:  "bare url",
+			input: "# T\n\nSee https://go.dev for more\n",
+			want:  []string{"3:5 bare-url"},
+		},
+		{
+			name:  "empty link",
+			input: "# T\n\n[nothing]() and [](https://go.dev)\n",
+			want:  []string{"3:1 empty-link", "3:17 empty-link"},
+		},
+		{
+			name:  "mixed list markers",
+			input: "# T\n\n- a\n- b\n\n* c\n",
+			want:  []string{"6:1 list-marker"},
+		},
+		{
+			name:   "configured list marker",
+			input:  "# T\n\n- a\n",
+			config: Config{"list-marker": {Options: Options{"style": "*"}}},
+			want:   []string{"3:1 list-marker"},
+		},
+		{
+			name:  "trailing whitespace",
+			input: "# T \n\nhard  \nbreak\n\nnot a break  \n",
+			want:  []string{"1:4 trailing-whitespace", "6:12 trailing-whitespace"},
+		},
+		{
+			name:  "missing fence language",
+			input: "# T\n\n```\ncode\n```\n",
+			want:  []string{"3:1 fence-language"},
+		},
+		{
+			name:   "disabled rule",
+			input:  "# T\n\n```\ncode\n```\n",
+			config: Config{"fence-language": {Enabled: new(bool)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lintString(t, tt.input, tt.config)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(map[string]any{
+		"heading-increment": map[string]any{"severity": "error", "enabled": true},
+		"list-marker":       map[string]any{"style": "+"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config["heading-increment"].Severity != SeverityError {
+		t.Errorf("severity = %q", config["heading-increment"].Severity)
+	}
+	if style := config["list-marker"].Options.String("style", ""); style != "+" {
+		t.Errorf("style = %q", style)
+	}
+
+	for _, raw := range []map[string]any{
+		{"no-such-rule": map[string]any{}},
+		{"list-marker": map[string]any{"style": "x"}},
+		{"bare-url": map[string]any{"severity": "fatal"}},
+	} {
+		if _, err := ParseConfig(raw); err == nil {
+			t.Errorf("ParseConfig(%v) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestConfigOnly(t *testing.T) {
+	disabled := false
+	config := Config{
+		"broken-link": {Enabled: &disabled, Severity: SeverityWarning},
+	}
+	only, err := config.Only([]string{"broken-link"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rule := range Rules() {
+		rc := only[rule.ID]
+		if want := rule.ID == "broken-link"; rc.Enabled == nil || *rc.Enabled != want {
+			t.Errorf("%s enabled = %v, want %v", rule.ID, rc.Enabled, want)
+		}
+	}
+	if only["broken-link"].Severity != SeverityWarning {
+		t.Errorf("severity = %q, want the configured one kept", only["broken-link"].Severity)
+	}
+	if _, err := config.Only([]string{"no-such-rule"}); err == nil {
+		t.Error("Only() accepted an unknown rule")
+	}
+}
+
+func TestFileFix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	input := "# Title  \n\nSee https://example.com and https://go.dev\n\n- a\n\n* b\n\n```\ncode\n```\n"
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := Config{"fence-language": {Options: Options{"default": "text"}}}
+	findings, err := File(path, config, testURLMap, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("remaining findings: %v", findings)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Title\n\nSee [sample website](https://example.com) and <https://go.dev>\n\n- a\n\n- b\n\n```text\ncode\n```\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("fixed file mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,298 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/gkwa/bravewaldo/core11"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+func init() {
+	register(Rule{
+		ID:          "heading-increment",
+		Description: "Heading levels should only increment by one level at a time",
+		Severity:    SeverityWarning,
+		Check:       checkHeadingIncrement,
+	})
+	register(Rule{
+		ID:          "duplicate-heading",
+		Description: "Headings should not repeat the text of an earlier heading",
+		Severity:    SeverityWarning,
+		Check:       checkDuplicateHeading,
+	})
+	register(Rule{
+		ID:          "bare-url",
+		Description: "URLs should be links or autolinks, not bare text",
+		Severity:    SeverityWarning,
+		Fixable:     true,
+		Check:       checkBareURL,
+	})
+	register(Rule{
+		ID:          "empty-link",
+		Description: "Links should have text and a destination",
+		Severity:    SeverityError,
+		Check:       checkEmptyLink,
+	})
+	register(Rule{
+		ID:          "list-marker",
+		Description: "Bullet lists should use one marker style (option style: consistent, -, * or +)",
+		Severity:    SeverityWarning,
+		Fixable:     true,
+		Check:       checkListMarker,
+		Validate: func(options Options) error {
+			if style := options.String("style", "consistent"); style != "consistent" && (len(style) != 1 || !strings.Contains("-*+", style)) {
+				return fmt.Errorf("invalid style %q", style)
+			}
+			return nil
+		},
+	})
+	register(Rule{
+		ID:          "trailing-whitespace",
+		Description: "Lines should not end in whitespace (option br-spaces allows hard line breaks)",
+		Severity:    SeverityWarning,
+		Fixable:     true,
+		Check:       checkTrailingWhitespace,
+	})
+	register(Rule{
+		ID:          "fence-language",
+		Description: "Fenced code blocks should declare a language (option default fixes them)",
+		Severity:    SeverityWarning,
+		Fixable:     true,
+		Check:       checkFenceLanguage,
+	})
+}
+
+// walk calls fn for every node entered in doc.
+func walk(doc *Document, fn func(n ast.Node)) {
+	_ = ast.Walk(doc.Root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			fn(n)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// lineSpan returns the span from offset to the end of its line.
+func lineSpan(source []byte, offset int) mdast.Span {
+	end := bytes.IndexByte(source[offset:], '\n')
+	if end < 0 {
+		return mdast.Span{Start: offset, Stop: len(source)}
+	}
+	return mdast.Span{Start: offset, Stop: offset + end}
+}
+
+func checkHeadingIncrement(doc *Document, _ Options) []Finding {
+	var findings []Finding
+	previous := 0
+	walk(doc, func(n ast.Node) {
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			return
+		}
+		if previous > 0 && heading.Level > previous+1 {
+			findings = append(findings, Finding{
+				Span:    lineSpan(doc.Source, heading.Pos()),
+				Message: fmt.Sprintf("heading level jumps from %d to %d", previous, heading.Level),
+			})
+		}
+		previous = heading.Level
+	})
+	return findings
+}
+
+func checkDuplicateHeading(doc *Document, _ Options) []Finding {
+	var findings []Finding
+	seen := map[string]bool{}
+	walk(doc, func(n ast.Node) {
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			return
+		}
+		text := mdast.PlainText(doc.Source, heading)
+		if seen[text] {
+			findings = append(findings, Finding{
+				Span:    lineSpan(doc.Source, heading.Pos()),
+				Message: fmt.Sprintf("duplicate heading %q", text),
+			})
+		}
+		seen[text] = true
+	})
+	return findings
+}
+
+// checkBareURL fixes bare URLs the way core11 does when the URL map has a
+// name for them, and with angle brackets otherwise.
+func checkBareURL(doc *Document, _ Options) []Finding {
+	var findings []Finding
+	walk(doc, func(n ast.Node) {
+		autoLink, ok := n.(*ast.AutoLink)
+		if !ok {
+			return
+		}
+		span, ok := mdast.AutoLinkSpan(doc.Source, autoLink)
+		if !ok || doc.Source[span.Start] == '<' {
+			return
+		}
+
+		label := string(autoLink.Label(doc.Source))
+		f := Finding{Span: span, Message: fmt.Sprintf("bare URL %s", label)}
+		if rewritten, ok := core11.RewriteURL(label, doc.URLMap); ok {
+			f.Fix = &Edit{Span: span, NewText: rewritten}
+		} else if autoLink.AutoLinkType == ast.AutoLinkURL && strings.Contains(label, "://") {
+			f.Fix = &Edit{Span: span, NewText: "<" + label + ">"}
+		}
+		findings = append(findings, f)
+	})
+	return findings
+}
+
+func checkEmptyLink(doc *Document, _ Options) []Finding {
+	var findings []Finding
+	walk(doc, func(n ast.Node) {
+		link, ok := n.(*ast.Link)
+		if !ok {
+			return
+		}
+		span := lineSpan(doc.Source, max(link.Pos(), 0))
+		if spans, ok := mdast.InlineLinkSpans(doc.Source, link); ok {
+			span = spans.Full
+		}
+
+		destination := string(link.Destination)
+		switch {
+		case destination == "" || destination == "#":
+			findings = append(findings, Finding{Span: span, Message: "link has an empty destination"})
+		case !link.HasChildren():
+			findings = append(findings, Finding{Span: span, Message: "link has no text"})
+		}
+	})
+	return findings
+}
+
+func checkListMarker(doc *Document, options Options) []Finding {
+	var findings []Finding
+	style := options.String("style", "consistent")
+	expected := byte(0)
+	if style != "consistent" {
+		expected = style[0]
+	}
+
+	walk(doc, func(n ast.Node) {
+		list, ok := n.(*ast.List)
+		if !ok || list.IsOrdered() {
+			return
+		}
+		if expected == 0 {
+			expected = list.Marker
+			return
+		}
+		if list.Marker == expected {
+			return
+		}
+		for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+			pos := item.Pos()
+			if pos < 0 || doc.Source[pos] != list.Marker {
+				continue
+			}
+			span := mdast.Span{Start: pos, Stop: pos + 1}
+			findings = append(findings, Finding{
+				Span:    span,
+				Message: fmt.Sprintf("list marker %q, expected %q", list.Marker, expected),
+				Fix:     &Edit{Span: span, NewText: string(expected)},
+			})
+		}
+	})
+	return findings
+}
+
+func checkTrailingWhitespace(doc *Document, options Options) []Finding {
+	var findings []Finding
+	brSpaces := options.Int("br-spaces", 2)
+
+	var code []mdast.Span
+	walk(doc, func(n ast.Node) {
+		switch n.Kind() {
+		case ast.KindCodeBlock, ast.KindFencedCodeBlock:
+			lines := n.Lines()
+			if lines.Len() > 0 {
+				code = append(code, mdast.Span{Start: lines.At(0).Start, Stop: lines.At(lines.Len() - 1).Stop})
+			}
+		}
+	})
+	inCode := func(offset int) bool {
+		for _, span := range code {
+			if span.Start <= offset && offset < span.Stop {
+				return true
+			}
+		}
+		return false
+	}
+
+	source := doc.Source
+	for start := 0; start < len(source); {
+		end := bytes.IndexByte(source[start:], '\n')
+		if end < 0 {
+			end = len(source)
+		} else {
+			end += start
+		}
+		next := end + 1
+
+		contentEnd := end
+		if contentEnd > start && source[contentEnd-1] == '\r' {
+			contentEnd--
+		}
+		trimmed := len(bytes.TrimRight(source[start:contentEnd], " \t"))
+		trailing := source[start+trimmed : contentEnd]
+
+		if len(trailing) > 0 && !inCode(contentEnd-1) && !isHardBreak(source, trimmed, trailing, next, brSpaces) {
+			span := mdast.Span{Start: start + trimmed, Stop: contentEnd}
+			findings = append(findings, Finding{
+				Span:    span,
+				Message: fmt.Sprintf("%d trailing whitespace characters", len(trailing)),
+				Fix:     &Edit{Span: span},
+			})
+		}
+		start = next
+	}
+	return findings
+}
+
+// isHardBreak reports whether trailing is exactly brSpaces spaces after
+// text and before another non-blank line.
+func isHardBreak(source []byte, trimmed int, trailing []byte, next, brSpaces int) bool {
+	if brSpaces < 2 || trimmed == 0 || len(trailing) != brSpaces || len(bytes.Trim(trailing, " ")) > 0 || next >= len(source) {
+		return false
+	}
+	return len(bytes.TrimSpace(lineSpan(source, next).Value(source))) > 0
+}
+
+func checkFenceLanguage(doc *Document, options Options) []Finding {
+	var findings []Finding
+	fallback := options.String("default", "")
+	walk(doc, func(n ast.Node) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !ok || len(block.Language(doc.Source)) > 0 || block.Pos() < 0 {
+			return
+		}
+
+		pos := block.Pos()
+		fence := pos
+		for fence < len(doc.Source) && (doc.Source[fence] == '`' || doc.Source[fence] == '~') {
+			fence++
+		}
+		f := Finding{
+			Span:    lineSpan(doc.Source, pos),
+			Message: "fenced code block has no language",
+		}
+		if fallback != "" && fence > pos {
+			f.Fix = &Edit{Span: mdast.Span{Start: fence, Stop: fence}, NewText: fallback}
+		}
+		findings = append(findings, f)
+	})
+	return findings
+}
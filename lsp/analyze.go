@@ -0,0 +1,81 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/core11"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+	"github.com/gkwa/bravewaldo/lint"
+)
+
+const (
+	codeBareURL           = "bare-url"
+	codeBrokenLink        = "broken-link"
+	codeInconsistentLabel = "inconsistent-label"
+)
+
+type finding struct {
+	code     string
+	severity int
+	span     mdast.Span
+	message  string
+	// fix replaces span when the finding can be fixed.
+	fix string
+}
+
+func parse(source []byte) ast.Node {
+	return core.NewGoldmark().Parser().Parse(text.NewReader(source))
+}
+
+func lookup(urlMap map[string]string, u string) (string, bool) {
+	name, ok := urlMap[strings.ToLower(u)]
+	return name, ok
+}
+
+// analyze reports bare URLs that have a URL map entry, plus the
+// broken-link and inconsistent-label findings of the lint rules.
+func analyze(path string, source []byte, urlMap map[string]string) []finding {
+	root := parse(source)
+	var findings []finding
+
+	for _, link := range mdast.Links(root, source) {
+		if _, ok := link.Node.(*ast.AutoLink); !ok {
+			continue
+		}
+		if rewritten, ok := core11.RewriteURL(link.URL, urlMap); ok {
+			name, _ := lookup(urlMap, link.URL)
+			findings = append(findings, finding{
+				code:     codeBareURL,
+				severity: SeverityInformation,
+				span:     link.Span,
+				message:  fmt.Sprintf("URL has the friendly name %q in the URL map", name),
+				fix:      rewritten,
+			})
+		}
+	}
+
+	doc := &lint.Document{Path: path, Source: source, Root: root, URLMap: urlMap}
+	for _, check := range []struct {
+		code     string
+		severity int
+	}{
+		{codeBrokenLink, SeverityWarning},
+		{codeInconsistentLabel, SeverityInformation},
+	} {
+		rule, _ := lint.Lookup(check.code)
+		for _, f := range rule.Check(doc, nil) {
+			findings = append(findings, finding{
+				code:     check.code,
+				severity: check.severity,
+				span:     f.Span,
+				message:  f.Message,
+			})
+		}
+	}
+	return findings
+}
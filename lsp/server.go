@@ -0,0 +1,368 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/yuin/goldmark/ast"
+
+	"github.com/gkwa/bravewaldo/core2"
+	"github.com/gkwa/bravewaldo/internal/mdast"
+)
+
+const sourceName = "bravewaldo"
+
+type Options struct {
+	// URLMap returns the URL map used for diagnostics, hovers, completions
+	// and code actions.
+	URLMap func() (map[string]string, error)
+}
+
+// Server is a Language Server Protocol server for markdown documents.
+type Server struct {
+	logger  logr.Logger
+	options Options
+
+	out      io.Writer
+	docs     map[string][]byte
+	shutdown bool
+}
+
+func New(logger logr.Logger, options Options) *Server {
+	return &Server{
+		logger:  logger,
+		options: options,
+		docs:    map[string][]byte{},
+	}
+}
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the client sends exit or closes r.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	reader := bufio.NewReader(r)
+
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+
+		s.logger.V(1).Info("Received", "method", msg.Method)
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, rpcErr := s.handle(msg)
+		if msg.ID == nil {
+			if rpcErr != nil {
+				s.logger.Error(errors.New(rpcErr.Message), "Failed to handle notification", "method", msg.Method)
+			}
+			continue
+		}
+
+		response := &message{ID: msg.ID, Error: rpcErr}
+		if rpcErr == nil {
+			response.Result, err = json.Marshal(result)
+			if err != nil {
+				return err
+			}
+		}
+		if err := writeMessage(s.out, response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) (any, *responseError) {
+	if s.shutdown && msg.Method != "exit" {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":           1,
+				"hoverProvider":              true,
+				"codeActionProvider":         true,
+				"documentFormattingProvider": true,
+				"completionProvider": map[string]any{
+					"triggerCharacters": []string{"("},
+				},
+			},
+			"serverInfo": map[string]string{"name": sourceName},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.docs[params.TextDocument.URI] = []byte(params.TextDocument.Text)
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = []byte(params.ContentChanges[n-1].Text)
+		}
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.hover(params)
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.completion(params)
+	case "textDocument/codeAction":
+		var params codeActionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.codeActions(params)
+	case "textDocument/formatting":
+		var params formattingParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.format(params)
+	}
+
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+func internalError(err error) *responseError {
+	return &responseError{Code: -32603, Message: err.Error()}
+}
+
+func (s *Server) urlMap() (map[string]string, *responseError) {
+	if s.options.URLMap == nil {
+		return map[string]string{}, nil
+	}
+	m, err := s.options.URLMap()
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return m, nil
+}
+
+// uriPath returns the file system path of a file URI, or "" for others.
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func (s *Server) document(uri string) ([]byte, *responseError) {
+	source, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: "unknown document: " + uri}
+	}
+	return source, nil
+}
+
+func (s *Server) publishDiagnostics(uri string) *responseError {
+	source, rpcErr := s.document(uri)
+	if rpcErr != nil {
+		return rpcErr
+	}
+	urlMap, rpcErr := s.urlMap()
+	if rpcErr != nil {
+		return rpcErr
+	}
+
+	diagnostics := []Diagnostic{}
+	for _, f := range analyze(uriPath(uri), source, urlMap) {
+		diagnostics = append(diagnostics, diagnostic(source, f))
+	}
+
+	params, err := json.Marshal(publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+	if err != nil {
+		return internalError(err)
+	}
+	if err := writeMessage(s.out, &message{Method: "textDocument/publishDiagnostics", Params: params}); err != nil {
+		return internalError(err)
+	}
+	return nil
+}
+
+func diagnostic(source []byte, f finding) Diagnostic {
+	return Diagnostic{
+		Range:    spanRange(source, f.span),
+		Severity: f.severity,
+		Code:     f.code,
+		Source:   sourceName,
+		Message:  f.message,
+	}
+}
+
+func spanRange(source []byte, span mdast.Span) Range {
+	return Range{Start: positionAt(source, span.Start), End: positionAt(source, span.Stop)}
+}
+
+func (s *Server) hover(params textDocumentPositionParams) (any, *responseError) {
+	source, rpcErr := s.document(params.TextDocument.URI)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	urlMap, rpcErr := s.urlMap()
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	offset := offsetAt(source, params.Position)
+	for _, link := range mdast.Links(parse(source), source) {
+		if _, ok := link.Node.(*ast.Image); ok || !link.Span.Contains(offset) {
+			continue
+		}
+		name, ok := lookup(urlMap, link.URL)
+		if !ok {
+			continue
+		}
+		var hover Hover
+		hover.Contents.Kind = "markdown"
+		hover.Contents.Value = fmt.Sprintf("**%s**\n\nURL map name for %s", name, link.URL)
+		hover.Range = spanRange(source, link.Span)
+		return hover, nil
+	}
+	return nil, nil
+}
+
+// completion offers URL map keys while the cursor is inside "](".
+func (s *Server) completion(params textDocumentPositionParams) (any, *responseError) {
+	source, rpcErr := s.document(params.TextDocument.URI)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	urlMap, rpcErr := s.urlMap()
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	offset := offsetAt(source, params.Position)
+	lineStart := strings.LastIndexByte(string(source[:offset]), '\n') + 1
+	line := string(source[lineStart:offset])
+
+	items := []CompletionItem{}
+	i := strings.LastIndex(line, "](")
+	if i < 0 {
+		return items, nil
+	}
+	prefix := line[i+2:]
+	if strings.ContainsAny(prefix, " \t)") {
+		return items, nil
+	}
+
+	editRange := Range{
+		Start: positionAt(source, offset-len(prefix)),
+		End:   params.Position,
+	}
+	for u, name := range urlMap {
+		if !strings.HasPrefix(strings.ToLower(u), strings.ToLower(prefix)) {
+			continue
+		}
+		items = append(items, CompletionItem{
+			Label:    u,
+			Kind:     18, // Reference
+			Detail:   name,
+			TextEdit: TextEdit{Range: editRange, NewText: u},
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items, nil
+}
+
+// codeActions offers the core11 rewrite for bare URLs in the range.
+func (s *Server) codeActions(params codeActionParams) (any, *responseError) {
+	uri := params.TextDocument.URI
+	source, rpcErr := s.document(uri)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	urlMap, rpcErr := s.urlMap()
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	requested := mdast.Span{
+		Start: offsetAt(source, params.Range.Start),
+		Stop:  offsetAt(source, params.Range.End),
+	}
+
+	actions := []CodeAction{}
+	for _, f := range analyze(uriPath(uri), source, urlMap) {
+		if f.fix == "" || f.span.Stop < requested.Start || f.span.Start > requested.Stop {
+			continue
+		}
+		actions = append(actions, CodeAction{
+			Title:       "Replace with " + f.fix,
+			Kind:        "quickfix",
+			Diagnostics: []Diagnostic{diagnostic(source, f)},
+			Edit: WorkspaceEdit{Changes: map[string][]TextEdit{
+				uri: {{Range: spanRange(source, f.span), NewText: f.fix}},
+			}},
+		})
+	}
+	return actions, nil
+}
+
+// format reformats the whole document with the goldmark-markdown renderer.
+func (s *Server) format(params formattingParams) (any, *responseError) {
+	source, rpcErr := s.document(params.TextDocument.URI)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	formatted, err := core2.Format(source)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	edits := []TextEdit{}
+	if string(formatted) != string(source) {
+		edits = append(edits, TextEdit{
+			Range:   Range{End: positionAt(source, len(source))},
+			NewText: string(formatted),
+		})
+	}
+	return edits, nil
+}
@@ -0,0 +1,218 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+var testURLMap = map[string]string{
+	"https://example.com": "sample website",
+	"https://google.com":  "search engine",
+}
+
+// client drives a Server through in-process pipes.
+type client struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	nextID int
+	done   chan error
+}
+
+func newClient(t *testing.T) *client {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	server := New(logr.Discard(), Options{
+		URLMap: func() (map[string]string, error) { return testURLMap, nil },
+	})
+	c := &client{t: t, in: clientOut, out: bufio.NewReader(clientIn), done: make(chan error, 1)}
+	go func() {
+		c.done <- server.Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+	t.Cleanup(func() {
+		c.notify("exit", nil)
+		if err := <-c.done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return c
+}
+
+func (c *client) send(msg *message) {
+	c.t.Helper()
+	if err := writeMessage(c.in, msg); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	raw, _ := json.Marshal(params)
+	c.send(&message{Method: method, Params: raw})
+}
+
+// call sends a request and decodes its result into result, skipping any
+// notifications that arrive first.
+func (c *client) call(method string, params, result any) {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(mustJSON(c.nextID))
+	raw, _ := json.Marshal(params)
+	c.send(&message{ID: &id, Method: method, Params: raw})
+
+	for {
+		msg := c.receive()
+		if msg.ID == nil {
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: %s", method, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return
+	}
+}
+
+func (c *client) receive() *message {
+	c.t.Helper()
+	msg, err := readMessage(c.out)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+func (c *client) open(uri, text string) publishDiagnosticsParams {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "markdown", "version": 1, "text": text},
+	})
+	msg := c.receive()
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("got %q, want publishDiagnostics", msg.Method)
+	}
+	var params publishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params
+}
+
+func mustJSON(v any) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func docAt(pos Position) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]string{"uri": testURI},
+		"position":     pos,
+	}
+}
+
+var testURI = "file:///docs/guide.md"
+
+func TestInitialize(t *testing.T) {
+	c := newClient(t)
+	var result struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	c.call("initialize", map[string]any{}, &result)
+	for _, capability := range []string{"hoverProvider", "codeActionProvider", "documentFormattingProvider", "completionProvider"} {
+		if _, ok := result.Capabilities[capability]; !ok {
+			t.Errorf("missing capability %s", capability)
+		}
+	}
+	c.call("shutdown", nil, nil)
+}
+
+func TestDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "exists.md"), []byte("# hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	uri := "file://" + filepath.ToSlash(filepath.Join(dir, "doc.md"))
+
+	c := newClient(t)
+	text := "See https://example.com today.\n\n" +
+		"[ok](exists.md) and [gone](missing.md#top)\n\n" +
+		"[Go](https://go.dev), [Go](https://go.dev) and [Golang](https://go.dev)\n"
+	params := c.open(uri, text)
+
+	got := map[string]Diagnostic{}
+	for _, d := range params.Diagnostics {
+		got[d.Code] = d
+	}
+	if len(params.Diagnostics) != 3 {
+		t.Fatalf("got %d diagnostics, want 3: %+v", len(params.Diagnostics), params.Diagnostics)
+	}
+
+	bare := got[codeBareURL]
+	if want := (Range{Start: Position{0, 4}, End: Position{0, 23}}); bare.Range != want {
+		t.Errorf("bare-url range = %+v, want %+v", bare.Range, want)
+	}
+	if broken := got[codeBrokenLink]; !strings.Contains(broken.Message, "missing.md") {
+		t.Errorf("broken-link message = %q", broken.Message)
+	}
+	if label := got[codeInconsistentLabel]; label.Range.Start != (Position{4, 47}) || !strings.Contains(label.Message, `"Golang"`) {
+		t.Errorf("inconsistent-label = %+v", label)
+	}
+}
+
+func TestCodeActionHoverCompletionAndFormatting(t *testing.T) {
+	c := newClient(t)
+	text := "#  Title\nSee https://example.com today.\n\nLink [x](https://g\n"
+	c.open(testURI, text)
+
+	var actions []CodeAction
+	c.call("textDocument/codeAction", map[string]any{
+		"textDocument": map[string]string{"uri": testURI},
+		"range":        Range{Start: Position{1, 6}, End: Position{1, 6}},
+		"context":      map[string]any{"diagnostics": []any{}},
+	}, &actions)
+	if len(actions) != 1 {
+		t.Fatalf("got %d code actions, want 1", len(actions))
+	}
+	edit := actions[0].Edit.Changes[testURI][0]
+	if edit.NewText != "[sample website](https://example.com)" {
+		t.Errorf("code action text = %q", edit.NewText)
+	}
+
+	var hover Hover
+	c.call("textDocument/hover", docAt(Position{1, 10}), &hover)
+	if !strings.Contains(hover.Contents.Value, "sample website") {
+		t.Errorf("hover = %q", hover.Contents.Value)
+	}
+
+	var items []CompletionItem
+	c.call("textDocument/completion", docAt(Position{3, 18}), &items)
+	if len(items) != 1 || items[0].Label != "https://google.com" || items[0].Detail != "search engine" {
+		t.Errorf("completion items = %+v", items)
+	}
+	if start := items[0].TextEdit.Range.Start; start != (Position{3, 9}) {
+		t.Errorf("completion edit start = %+v", start)
+	}
+
+	var edits []TextEdit
+	c.call("textDocument/formatting", map[string]any{
+		"textDocument": map[string]string{"uri": testURI},
+		"options":      map[string]any{"tabSize": 4, "insertSpaces": true},
+	}, &edits)
+	if len(edits) != 1 || !strings.HasPrefix(edits[0].NewText, "# Title\n") {
+		t.Errorf("formatting edits = %+v", edits)
+	}
+}
@@ -0,0 +1,282 @@
+package serve
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	core "github.com/gkwa/bravewaldo/core1"
+	"github.com/gkwa/bravewaldo/core10"
+	"github.com/gkwa/bravewaldo/internal/files"
+)
+
+// EventsPath is where browsers subscribe to live reload events.
+const EventsPath = "/_bravewaldo/events"
+
+var indexNames = []string{"index.md", "README.md"}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 50em; margin: 2em auto; padding: 0 1em; font-family: sans-serif; line-height: 1.5; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 0.3em 0.6em; }
+</style>
+</head>
+<body>
+{{.Body}}
+<script>
+new EventSource("{{.Events}}").onmessage = function() { location.reload(); };
+</script>
+</body>
+</html>
+`))
+
+type page struct {
+	Title  string
+	Body   template.HTML
+	Events string
+}
+
+type Options struct {
+	// Root is the directory whose markdown files and assets are served.
+	Root string
+	// URLMap returns the URL map used to rewrite autolinks. It is called
+	// for every page, so changes to the map show up on the next reload.
+	URLMap func() (map[string]string, error)
+}
+
+// Server renders markdown to HTML on request and pushes reload events to
+// open pages over Server-Sent Events.
+type Server struct {
+	logger  logr.Logger
+	options Options
+
+	mu          sync.Mutex
+	subscribers map[chan string]bool
+}
+
+func New(logger logr.Logger, options Options) *Server {
+	return &Server{
+		logger:      logger,
+		options:     options,
+		subscribers: map[chan string]bool{},
+	}
+}
+
+// Notify tells every open page that path changed. Its signature matches
+// the process function of watch.Watcher.
+func (s *Server) Notify(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logger.V(1).Info("Notifying pages", "path", path, "subscribers", len(s.subscribers))
+	for ch := range s.subscribers {
+		select {
+		case ch <- path:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *Server) subscribe() chan string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan string, 1)
+	s.subscribers[ch] = true
+	return ch
+}
+
+func (s *Server) unsubscribe(ch chan string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscribers, ch)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	urlPath := path.Clean("/" + r.URL.Path)
+	s.logger.V(1).Info("Request", "method", r.Method, "path", urlPath)
+
+	if urlPath == EventsPath {
+		s.serveEvents(w, r)
+		return
+	}
+
+	// Dotfiles such as .git or the config file are not part of the docs.
+	for _, segment := range strings.Split(urlPath, "/") {
+		if strings.HasPrefix(segment, ".") {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	name := filepath.Join(s.options.Root, filepath.FromSlash(urlPath))
+	info, err := os.Stat(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if info.IsDir() {
+		for _, index := range indexNames {
+			if _, err := os.Stat(filepath.Join(name, index)); err == nil {
+				s.serveMarkdown(w, filepath.Join(name, index))
+				return
+			}
+		}
+		s.serveListing(w, name, urlPath)
+		return
+	}
+
+	if files.IsMarkdown(name) {
+		s.serveMarkdown(w, name)
+		return
+	}
+
+	http.ServeFile(w, r, name)
+}
+
+func (s *Server) serveMarkdown(w http.ResponseWriter, name string) {
+	body, title, err := s.render(name)
+	if err != nil {
+		s.logger.Error(err, "Failed to render markdown", "path", name)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.writePage(w, page{Title: title, Body: template.HTML(body)})
+}
+
+// render converts the markdown file name to HTML using the goldmark setup
+// from core1 and the autolink rewriting from core10.
+func (s *Server) render(name string) ([]byte, string, error) {
+	source, err := os.ReadFile(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	md := core.NewGoldmark()
+	pc := parser.NewContext()
+	doc := md.Parser().Parse(text.NewReader(source), parser.WithContext(pc))
+
+	if s.options.URLMap != nil {
+		urlMap, err := s.options.URLMap()
+		if err != nil {
+			return nil, "", err
+		}
+		if err := core10.RewriteAutoLinks(doc, source, urlMap); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		return nil, "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+
+	return buf.Bytes(), title(doc, source, pc, name), nil
+}
+
+// title prefers the front matter title, then the first heading, then the
+// file name.
+func title(doc ast.Node, source []byte, pc parser.Context, name string) string {
+	if t, ok := meta.Get(pc)["title"].(string); ok && t != "" {
+		return t
+	}
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if heading, ok := n.(*ast.Heading); ok {
+			return string(heading.Lines().Value(source))
+		}
+	}
+	return filepath.Base(name)
+}
+
+func (s *Server) serveListing(w http.ResponseWriter, dir, urlPath string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if entry.IsDir() {
+			names = append(names, entry.Name()+"/")
+		} else if files.IsMarkdown(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<h1>%s</h1>\n<ul>\n", template.HTMLEscapeString(urlPath))
+	for _, name := range names {
+		href := path.Join(urlPath, name)
+		if strings.HasSuffix(name, "/") {
+			href += "/"
+		}
+		fmt.Fprintf(&buf, "<li><a href=\"%s\">%s</a></li>\n",
+			template.HTMLEscapeString(href), template.HTMLEscapeString(name))
+	}
+	buf.WriteString("</ul>\n")
+
+	s.writePage(w, page{Title: urlPath, Body: template.HTML(buf.String())})
+}
+
+func (s *Server) writePage(w http.ResponseWriter, p page) {
+	p.Events = EventsPath
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, p); err != nil {
+		s.logger.Error(err, "Failed to write page")
+	}
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case changed := <-ch:
+			fmt.Fprintf(w, "data: %s\n\n", filepath.ToSlash(changed))
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "index.md"), "---\ntitle: Home\n---\n# Welcome\n\nSee https://example.com and [other](other.md).\n")
+	writeFile(t, filepath.Join(root, "style.css"), "body {}\n")
+
+	srv := New(logr.Discard(), Options{
+		Root: root,
+		URLMap: func() (map[string]string, error) {
+			return map[string]string{"https://example.com": "sample website"}, nil
+		},
+	})
+	return srv, root
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServeMarkdown(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"<title>Home</title>",
+		`<h1 id="welcome">Welcome</h1>`,
+		`<a href="https://example.com">sample website</a>`,
+		`<a href="other.md">other</a>`,
+		"new EventSource(",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("response does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestServeStaticAndMissing(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/style.css", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "body {}\n" {
+		t.Errorf("static asset: code %d body %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/../../etc/passwd", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("path outside root: code %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestServeRefusesDotfiles(t *testing.T) {
+	srv, root := newTestServer(t)
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, ".git", "config"), "[core]\n")
+	writeFile(t, filepath.Join(root, ".bravewaldo.yaml"), "url-map: urls.yaml\n")
+
+	for _, target := range []string{"/.git/config", "/.git/", "/.bravewaldo.yaml"} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: code %d, want %d", target, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestServeEvents(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+EventsPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, ":") {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+
+	if err := srv.Notify("index.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "data:") {
+			if got := strings.TrimSpace(line); got != "data: index.md" {
+				t.Errorf("event = %q", got)
+			}
+			return
+		}
+	}
+}